@@ -0,0 +1,351 @@
+package planhat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Aggregation is the way a MetricAggregator combines the values of a dimension for a day.
+type Aggregation int
+
+// Aggregations supported by MetricAggregator.
+const (
+	AggregateSum Aggregation = iota
+	AggregateLast
+	AggregateMax
+	AggregateMin
+	AggregateAverage
+)
+
+func (a Aggregation) String() string {
+	switch a {
+	case AggregateSum:
+		return "sum"
+	case AggregateLast:
+		return "last"
+	case AggregateMax:
+		return "max"
+	case AggregateMin:
+		return "min"
+	case AggregateAverage:
+		return "average"
+	}
+	return fmt.Sprintf("Aggregation(%d)", int(a))
+}
+
+// maxMetricsBatch is the number of metrics sent per request when flushing.
+const maxMetricsBatch = 5000
+
+// MetricAggregator rolls up metric values in memory before they are sent, as planhat keeps a single
+// value per dimension, model and day.  Values added are combined by dimensionId, externalId, model and
+// the UTC day of their Date, or of the time they were added if they have none, using the aggregation
+// set for their dimension.  Flush sends the aggregates that have changed using BulkUpsert, each with
+// the running value for its whole day, so that each flush replaces the day's value in planhat with
+// a complete one.  An aggregate is kept until its day has ended and it has been sent, so values
+// arriving for a day after that start a new aggregate whose value replaces the earlier one:
+//
+//	agg := planhat.NewMetricAggregator(ph.MetricsService, planhat.AggregateSum)
+//	agg.SetAggregation("activeusers", planhat.AggregateMax)
+//	agg.Add(planhat.Metric{DimensionID: planhat.String("logins"), Value: planhat.Float64(1), ExternalID: planhat.String("acme")})
+//	...
+//	_, err := agg.Flush(ctx)
+//
+// It is safe for concurrent use.
+type MetricAggregator struct {
+	metrics MetricsAPI
+	def     Aggregation
+	now     func() time.Time
+
+	normalise bool
+
+	flushMu sync.Mutex // held by Flush so that an aggregate isn't dropped while being sent
+
+	mu      sync.Mutex
+	aggs    map[string]Aggregation
+	buckets map[aggregateKey]*aggregate
+}
+
+type aggregateKey struct {
+	dimensionID string
+	externalID  string
+	model       string
+	day         int
+}
+
+// aggregate holds enough state to apply any aggregation.
+type aggregate struct {
+	sum, min, max, last float64
+	count               int
+	lastTime            time.Time
+	dirty               bool // changed since it was last sent
+}
+
+func (a *aggregate) add(v float64, t time.Time) {
+	if a.count == 0 || v < a.min {
+		a.min = v
+	}
+	if a.count == 0 || v > a.max {
+		a.max = v
+	}
+	if a.count == 0 || !t.Before(a.lastTime) {
+		a.last, a.lastTime = v, t
+	}
+	a.sum += v
+	a.count++
+	a.dirty = true
+}
+
+func (a *aggregate) value(agg Aggregation) float64 {
+	switch agg {
+	case AggregateLast:
+		return a.last
+	case AggregateMax:
+		return a.max
+	case AggregateMin:
+		return a.min
+	case AggregateAverage:
+		return a.sum / float64(a.count)
+	}
+	return a.sum
+}
+
+// NewMetricAggregator returns a MetricAggregator sending to metrics, usually the MetricsService,
+// and combining values with def unless SetAggregation is used for their dimension.  Dimension IDs
+// are normalised if metrics is a MetricsService whose client was created using
+// WithDimensionNormalisation.
+func NewMetricAggregator(metrics MetricsAPI, def Aggregation) *MetricAggregator {
+	return &MetricAggregator{
+		metrics:   metrics,
+		def:       def,
+		now:       time.Now,
+		normalise: normalisesDimensions(metrics),
+		aggs:      map[string]Aggregation{},
+		buckets:   map[aggregateKey]*aggregate{},
+	}
+}
+
+// SetDimensionNormalisation sets whether the DimensionID of each metric added is normalised using
+// NormaliseDimensionID, so that e.g. "Active Users" and "activeusers" are combined.  It should be set
+// before any metrics are added.  Enabling it also normalises the dimension IDs of the aggregations
+// already set.
+func (a *MetricAggregator) SetDimensionNormalisation(enabled bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.normalise = enabled
+	if enabled {
+		aggs := make(map[string]Aggregation, len(a.aggs))
+		for id, agg := range a.aggs {
+			aggs[NormaliseDimensionID(id)] = agg
+		}
+		a.aggs = aggs
+	}
+}
+
+// SetAggregation sets the aggregation used for a dimension.  If dimension IDs are normalised,
+// dimensionID is normalised too, so e.g. "Active Users" sets the aggregation for "activeusers".
+func (a *MetricAggregator) SetAggregation(dimensionID string, agg Aggregation) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.normalise {
+		dimensionID = NormaliseDimensionID(dimensionID)
+	}
+	a.aggs[dimensionID] = agg
+}
+
+// Add adds a metric value to its aggregate.  It returns a *ValidationError if the metric is invalid.
+func (a *MetricAggregator) Add(m Metric) error {
+	a.mu.Lock()
+	normalise := a.normalise
+	a.mu.Unlock()
+	if normalise && m.DimensionID != nil {
+		m.DimensionID = String(NormaliseDimensionID(*m.DimensionID))
+	}
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	t, ok := m.Time()
+	if !ok {
+		t = a.now()
+	}
+	key := aggregateKey{
+		dimensionID: m.GetDimensionID(),
+		externalID:  m.GetExternalID(),
+		model:       m.GetModel(),
+		day:         EpochDays(t),
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	b, ok := a.buckets[key]
+	if !ok {
+		b = &aggregate{}
+		a.buckets[key] = b
+	}
+	b.add(*m.Value, t)
+	return nil
+}
+
+// Len returns the number of aggregates held, including those already sent for the current day.
+func (a *MetricAggregator) Len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.buckets)
+}
+
+// Metrics returns the aggregates held as metrics, sorted by day, dimensionId and externalId.
+func (a *MetricAggregator) Metrics() []Metric {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.toMetrics(a.sortedKeys(func(*aggregate) bool { return true }))
+}
+
+// toMetrics converts the aggregates with the given keys to metrics.  a.mu must be held.
+func (a *MetricAggregator) toMetrics(keys []aggregateKey) []Metric {
+	metrics := make([]Metric, len(keys))
+	for i, k := range keys {
+		agg, ok := a.aggs[k.dimensionID]
+		if !ok {
+			agg = a.def
+		}
+		metrics[i] = Metric{
+			DimensionID: String(k.dimensionID),
+			ExternalID:  String(k.externalID),
+			Value:       Float64(a.buckets[k].value(agg)),
+			Date:        MetricDate(FromEpochDays(k.day)),
+		}
+		if k.model != "" {
+			metrics[i].Model = String(k.model)
+		}
+	}
+	return metrics
+}
+
+// sortedKeys returns the keys of the aggregates for which include returns true, sorted by day,
+// dimensionId, externalId and model.  a.mu must be held.
+func (a *MetricAggregator) sortedKeys(include func(*aggregate) bool) []aggregateKey {
+	keys := make([]aggregateKey, 0, len(a.buckets))
+	for k, b := range a.buckets {
+		if include(b) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.day != b.day {
+			return a.day < b.day
+		}
+		if a.dimensionID != b.dimensionID {
+			return a.dimensionID < b.dimensionID
+		}
+		if a.externalID != b.externalID {
+			return a.externalID < b.externalID
+		}
+		return a.model < b.model
+	})
+	return keys
+}
+
+// Flush sends the aggregates that have changed since they were last sent with BulkUpsert, in batches
+// of up to 5,000, then drops those for days that have ended.  If a batch fails, it and the remaining
+// batches are sent by the next Flush.  The responses of the batches sent are combined.
+//
+// Aggregates rejected as invalid when sent, such as by a DimensionRegistry, can never be sent, so
+// they are dropped and the rest of their batch sent again.  Once the remaining batches have been
+// sent a *BulkValidationError is returned listing them, indexed by their position among the
+// aggregates flushed, which are sorted as by Metrics.
+func (a *MetricAggregator) Flush(ctx context.Context) (*UpsertMetricsResponse, error) {
+	a.flushMu.Lock()
+	defer a.flushMu.Unlock()
+	a.mu.Lock()
+	keys := a.sortedKeys(func(b *aggregate) bool { return b.dirty })
+	metrics := a.toMetrics(keys)
+	for _, k := range keys {
+		a.buckets[k].dirty = false
+	}
+	a.mu.Unlock()
+
+	resp := &UpsertMetricsResponse{}
+	var invalid []ItemError
+	for start := 0; start < len(metrics); start += maxMetricsBatch {
+		end := min(start+maxMetricsBatch, len(metrics))
+		batch := metrics[start:end]
+		pos := make([]int, len(batch)) // the index in metrics of each metric in batch
+		for i := range pos {
+			pos[i] = start + i
+		}
+		r, err := a.metrics.BulkUpsert(ctx, batch)
+		var bve *BulkValidationError
+		for errors.As(err, &bve) && len(bve.Items) > 0 {
+			skip := make(map[int]bool, len(bve.Items))
+			a.mu.Lock()
+			for _, item := range bve.Items {
+				skip[item.Index] = true
+				delete(a.buckets, keys[pos[item.Index]])
+				invalid = append(invalid, ItemError{Index: pos[item.Index], Err: item.Err})
+			}
+			a.mu.Unlock()
+			var keep []int
+			for i := range pos {
+				if !skip[i] {
+					keep = append(keep, pos[i])
+				}
+			}
+			pos, batch = keep, make([]Metric, len(keep))
+			for i, p := range pos {
+				batch[i] = metrics[p]
+			}
+			if len(batch) == 0 {
+				r, err = nil, nil
+				break
+			}
+			r, err = a.metrics.BulkUpsert(ctx, batch)
+		}
+		if err != nil {
+			a.mu.Lock()
+			for _, p := range pos {
+				a.buckets[keys[p]].dirty = true
+			}
+			for _, k := range keys[end:] {
+				a.buckets[k].dirty = true
+			}
+			a.mu.Unlock()
+			return resp, err
+		}
+		if r != nil {
+			resp.Processed += r.Processed
+			resp.Errors = append(resp.Errors, r.Errors...)
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	today := EpochDays(a.now())
+	for k, b := range a.buckets {
+		if k.day < today && !b.dirty {
+			delete(a.buckets, k)
+		}
+	}
+	sort.Slice(invalid, func(i, j int) bool { return invalid[i].Index < invalid[j].Index })
+	return resp, bulkError(len(metrics), invalid)
+}
+
+// Run calls Flush every interval until ctx is done, passing any errors to onError if it is not nil.
+// It blocks, so is usually run in its own goroutine.  Aggregates not yet flushed when ctx is done
+// remain in the aggregator.
+func (a *MetricAggregator) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if _, err := a.Flush(ctx); err != nil && onError != nil && ctx.Err() == nil {
+				onError(err)
+			}
+		}
+	}
+}
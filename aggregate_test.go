@@ -0,0 +1,205 @@
+package planhat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeMetrics struct {
+	MetricsAPI
+	sent [][]Metric
+	err  error
+}
+
+func (f *fakeMetrics) BulkUpsert(ctx context.Context, metrics []Metric) (*UpsertMetricsResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.sent = append(f.sent, metrics)
+	return &UpsertMetricsResponse{Processed: len(metrics)}, nil
+}
+
+func metricString(m Metric) string {
+	return fmt.Sprintf("%s/%s/%s/%s=%g", m.GetDimensionID(), m.GetExternalID(), m.GetModel(), m.GetDate(), *m.Value)
+}
+
+func TestMetricAggregator(t *testing.T) {
+	day := time.Date(2021, 3, 1, 9, 0, 0, 0, time.UTC)
+	fake := &fakeMetrics{}
+	agg := NewMetricAggregator(fake, AggregateSum)
+	agg.now = func() time.Time { return day }
+	agg.SetAggregation("seats", AggregateLast)
+	agg.SetAggregation("peak", AggregateMax)
+	agg.SetAggregation("low", AggregateMin)
+	agg.SetAggregation("nps", AggregateAverage)
+
+	add := func(dim, ext string, v float64, at time.Time) {
+		t.Helper()
+		if err := agg.Add(Metric{DimensionID: String(dim), ExternalID: String(ext), Value: Float64(v), Date: MetricDate(at)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 1; i <= 3; i++ {
+		add("logins", "a", 1, day)
+		add("peak", "a", float64(i), day)
+		add("low", "a", float64(i), day)
+		add("nps", "a", float64(i*2), day)
+	}
+	add("logins", "b", 5, day)
+	add("logins", "a", 1, day.AddDate(0, 0, 1))
+	add("seats", "a", 10, day.Add(time.Hour))
+	add("seats", "a", 7, day)
+	if err := agg.Add(Metric{DimensionID: String("logins"), ExternalID: String("a"), Value: Float64(1), Model: String("EndUser")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := agg.Add(Metric{DimensionID: String("logins")}); !errors.Is(err, ErrValidation) {
+		t.Errorf("Add invalid metric err = %v", err)
+	}
+	if agg.Len() != 8 {
+		t.Fatalf("Len = %d, want 8", agg.Len())
+	}
+
+	resp, err := agg.Flush(context.Background())
+	if err != nil || resp.Processed != 8 || len(fake.sent) != 1 {
+		t.Fatalf("Flush = %+v, %v", resp, err)
+	}
+	var got []string
+	for _, m := range fake.sent[0] {
+		got = append(got, metricString(m))
+	}
+	want := []string{
+		"logins/a//2021-03-01T00:00:00.000Z=3",
+		"logins/a/EndUser/2021-03-01T00:00:00.000Z=1",
+		"logins/b//2021-03-01T00:00:00.000Z=5",
+		"low/a//2021-03-01T00:00:00.000Z=1",
+		"nps/a//2021-03-01T00:00:00.000Z=4",
+		"peak/a//2021-03-01T00:00:00.000Z=3",
+		"seats/a//2021-03-01T00:00:00.000Z=10",
+		"logins/a//2021-03-02T00:00:00.000Z=1",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("sent\n%v\nwant\n%v", got, want)
+	}
+	if agg.Len() != 8 {
+		t.Errorf("Len after Flush = %d, want 8 as the day hasn't ended", agg.Len())
+	}
+}
+
+func TestMetricAggregatorFlushTwice(t *testing.T) {
+	day := time.Date(2021, 3, 1, 9, 0, 0, 0, time.UTC)
+	fake := &fakeMetrics{}
+	agg := NewMetricAggregator(fake, AggregateSum)
+	agg.now = func() time.Time { return day }
+	agg.SetAggregation("peak", AggregateMax)
+	add := func(dim string, v float64) {
+		agg.Add(Metric{DimensionID: String(dim), ExternalID: String("a"), Value: Float64(v)})
+	}
+	ctx := context.Background()
+
+	add("logins", 2)
+	add("peak", 5)
+	add("seats", 1)
+	agg.Flush(ctx)
+	add("logins", 3)
+	add("peak", 4)
+	agg.Flush(ctx)
+
+	// The second flush sends the running values for the day, and only those that changed.
+	var got []string
+	for _, m := range fake.sent[1] {
+		got = append(got, metricString(m))
+	}
+	want := []string{
+		"logins/a//2021-03-01T00:00:00.000Z=5",
+		"peak/a//2021-03-01T00:00:00.000Z=5",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("second flush sent %v, want %v", got, want)
+	}
+
+	// Once the day has ended and been sent, its aggregates are dropped.
+	day = day.AddDate(0, 0, 1)
+	if _, err := agg.Flush(ctx); err != nil || agg.Len() != 0 || len(fake.sent) != 2 {
+		t.Errorf("after the day ended: Len = %d, %d flushes sent, err %v", agg.Len(), len(fake.sent), err)
+	}
+}
+
+func TestMetricAggregatorFlushError(t *testing.T) {
+	day := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	fake := &fakeMetrics{err: errors.New("unavailable")}
+	agg := NewMetricAggregator(fake, AggregateAverage)
+	m := Metric{DimensionID: String("nps"), ExternalID: String("a"), Value: Float64(2), Date: MetricDate(day)}
+	agg.Add(m)
+	if _, err := agg.Flush(context.Background()); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// Values added after a failed flush are combined with those not sent.
+	m.Value = Float64(4)
+	agg.Add(m)
+	fake.err = nil
+	if _, err := agg.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.sent) != 1 || len(fake.sent[0]) != 1 || *fake.sent[0][0].Value != 3 {
+		t.Errorf("sent %v", fake.sent)
+	}
+}
+
+func TestMetricAggregatorInvalid(t *testing.T) {
+	// Aggregates rejected when sent, e.g. by a DimensionRegistry, are dropped and the rest sent.
+	dry := NewDryRun(nil)
+	c, _ := New("key", WithTenantUUID("t"), WithDryRun(dry), WithDimensionRegistry(NewDimensionRegistry(false, "logins")))
+	agg := NewMetricAggregator(c.MetricsService, AggregateSum)
+	for _, dim := range []string{"logins", "logns"} {
+		agg.Add(Metric{DimensionID: String(dim), ExternalID: String("a"), Value: Float64(1)})
+	}
+	var bve *BulkValidationError
+	resp, err := agg.Flush(context.Background())
+	if !errors.As(err, &bve) || len(bve.Items) != 1 || bve.Items[0].Index != 1 || bve.Total != 2 {
+		t.Fatalf("Flush err = %v", err)
+	}
+	if resp.Processed != 1 || agg.Len() != 1 {
+		t.Errorf("Processed = %d, Len = %d", resp.Processed, agg.Len())
+	}
+	reqs := dry.Requests()
+	if len(reqs) != 1 || strings.Contains(string(reqs[0].Body), "logns") {
+		t.Errorf("sent %v", reqs)
+	}
+	if _, err := agg.Flush(context.Background()); err != nil || len(dry.Requests()) != 1 {
+		t.Errorf("second Flush err = %v, %d requests", err, len(dry.Requests()))
+	}
+}
+
+func TestMetricAggregatorNormalisation(t *testing.T) {
+	c, _ := New("key", WithDimensionNormalisation(true))
+	agg := NewMetricAggregator(c.MetricsService, AggregateSum)
+	agg.SetAggregation("Active Users", AggregateMax)
+	for _, dim := range []string{"Active Users", "activeusers"} {
+		if err := agg.Add(Metric{DimensionID: String(dim), ExternalID: String("a"), Value: Float64(2)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if m := agg.Metrics(); len(m) != 1 || m[0].GetDimensionID() != "activeusers" || *m[0].Value != 2 {
+		t.Errorf("Metrics = %v", m)
+	}
+
+	agg = NewMetricAggregator(&fakeMetrics{}, AggregateSum)
+	agg.SetAggregation("Active Users", AggregateMax)
+	if err := agg.Add(Metric{DimensionID: String("Active Users"), ExternalID: String("a"), Value: Float64(2)}); err == nil {
+		t.Error("expected invalid dimension without normalisation")
+	}
+	agg.SetDimensionNormalisation(true)
+	for i := 0; i < 2; i++ {
+		if err := agg.Add(Metric{DimensionID: String("Active Users"), ExternalID: String("a"), Value: Float64(2)}); err != nil {
+			t.Errorf("Add with normalisation: %v", err)
+		}
+	}
+	if m := agg.Metrics(); len(m) != 1 || *m[0].Value != 2 {
+		t.Errorf("Metrics after enabling normalisation = %v", m)
+	}
+}
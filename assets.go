@@ -2,10 +2,6 @@ package planhat
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"net/http"
-	"strings"
 )
 
 // AssetListOptions represents query parameters for listing assets.  They are pointer values
@@ -41,41 +37,33 @@ type Asset struct {
 
 // Create creates a new asset record
 func (s *AssetService) Create(ctx context.Context, asset Asset) (*Asset, error) {
-	as := &Asset{}
-	url := fmt.Sprintf("%s/assets", s.client.BaseURL)
-	payload, err := json.Marshal(asset)
-	if err != nil {
-		return as, err
-	}
-	req, err := http.NewRequest("POST", url, strings.NewReader(string(payload)))
-	if err != nil {
-		return as, err
-	}
-	if err := s.client.makeRequest(ctx, req, as); err != nil {
-		return as, err
-	}
-	return as, nil
+	return s.resource.Create(ctx, asset)
 }
 
 // Update will update a planhat asset.
 // To update an asset it is required to pass the asset _id in the request.
 // Alternately it is possible to update using the asset externalId and/or sourceId adding a prefix and passing one of
-// these keyables as identifiers. e.g. extid-{{externalId}} or srcid-{{sourceId}}
+// these keyables as identifiers. e.g. extid-{{externalId}} or srcid-{{sourceId}}, or by using UpdateByKey which
+// will also escape them for you.
 func (s *AssetService) Update(ctx context.Context, id string, asset Asset) (*Asset, error) {
-	as := &Asset{}
-	url := fmt.Sprintf("%s/assets/%s", s.client.BaseURL, id)
-	payload, err := json.Marshal(asset)
-	if err != nil {
-		return as, err
-	}
-	req, err := http.NewRequest("PUT", url, strings.NewReader(string(payload)))
-	if err != nil {
-		return as, err
-	}
-	if err := s.client.makeRequest(ctx, req, as); err != nil {
-		return as, err
-	}
-	return as, nil
+	return s.resource.Update(ctx, id, asset)
+}
+
+// UpdateByKey will update an asset identified by key, e.g. planhat.ByExternalID("123").
+func (s *AssetService) UpdateByKey(ctx context.Context, key Key, asset Asset) (*Asset, error) {
+	return s.resource.UpdateByKey(ctx, key, asset)
+}
+
+// UpdateFields applies a partial update to an asset identified by key, sending only the fields set or
+// cleared in update.  Use it to clear fields, which Update can't do as empty fields are omitted.
+func (s *AssetService) UpdateFields(ctx context.Context, key Key, update *AssetUpdate) (*Asset, error) {
+	return s.resource.UpdateFields(ctx, key, update)
+}
+
+// UpdateIfChanged fetches an asset identified by key and updates only the fields of asset that differ,
+// sending nothing if none do.  It returns the asset and the changes made.
+func (s *AssetService) UpdateIfChanged(ctx context.Context, key Key, asset Asset) (*Asset, []Change, error) {
+	return s.resource.UpdateIfChanged(ctx, key, asset)
 }
 
 // Get returns a single asset given it's planhat ID
@@ -83,62 +71,49 @@ func (s *AssetService) Update(ctx context.Context, id string, asset Asset) (*Ass
 // these keyables as identifiers. e.g. extid-{{externalId}} or srcid-{{sourceId}}.  Helper functions have also
 // been provided for this.
 func (s *AssetService) Get(ctx context.Context, id string) (*Asset, error) {
-	as := &Asset{}
-	url := fmt.Sprintf("%s/assets/%s", s.client.BaseURL, id)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return as, err
-	}
-	if err := s.client.makeRequest(ctx, req, &as); err != nil {
-		return as, err
-	}
-	return as, nil
+	return s.resource.Get(ctx, id)
+}
+
+// GetByKey retrieves an asset identified by key, e.g. planhat.BySourceID("123").
+func (s *AssetService) GetByKey(ctx context.Context, key Key) (*Asset, error) {
+	return s.resource.GetByKey(ctx, key)
 }
 
 // GetByExternalID retrieves an asset using it's external ID
 func (s *AssetService) GetByExternalID(ctx context.Context, externalID string) (*Asset, error) {
-	return s.Get(ctx, fmt.Sprintf("extid-%s", externalID))
+	return s.resource.GetByExternalID(ctx, externalID)
 }
 
 // GetBySourceID retrieves an asset using it's source ID
 func (s *AssetService) GetBySourceID(ctx context.Context, sourceID string) (*Asset, error) {
-	return s.Get(ctx, fmt.Sprintf("srcid-%s", sourceID))
+	return s.resource.GetBySourceID(ctx, sourceID)
+}
+
+// GetMany retrieves the assets identified by keys concurrently, returning a result for each key in
+// the same order.  A missing asset is reported as ErrNotFound in its result.
+func (s *AssetService) GetMany(ctx context.Context, keys []Key) []Result[Asset] {
+	return s.resource.GetMany(ctx, keys)
 }
 
 // List will list assets based on the AssetListOptions provided
 func (s *AssetService) List(ctx context.Context, options ...*AssetListOptions) ([]*Asset, error) {
-	ar := []*Asset{}
-
-	url := fmt.Sprintf("%s/assets", s.client.BaseURL)
-	for _, option := range options {
-		var err error
-		url, err = addOptions(url, option)
-		if err != nil {
-			return ar, err
-		}
-	}
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return ar, err
-	}
-	if err := s.client.makeRequest(ctx, req, &ar); err != nil {
-		return ar, err
-	}
-	return ar, nil
-}
-
-// Delete is used delete an asset. It is required to pass the _id (ID).
+	return s.resource.List(ctx, options...)
+}
+
+// ListEach calls fn with each asset matching the AssetListOptions provided, without holding them all
+// in memory.  Returning an error from fn stops the listing and returns that error.
+func (s *AssetService) ListEach(ctx context.Context, fn func(*Asset) error, options ...*AssetListOptions) error {
+	return s.resource.ListEach(ctx, fn, options...)
+}
+
+// Delete is used delete an asset using its _id (ID).  Use DeleteByKey to delete using its externalId or sourceId.
 func (s *AssetService) Delete(ctx context.Context, id string) (*DeleteResponse, error) {
-	url := fmt.Sprintf("%s/assets/%s", s.client.BaseURL, id)
-	req, err := http.NewRequest("DELETE", url, nil)
-	if err != nil {
-		return nil, err
-	}
-	dr := &DeleteResponse{}
-	if err := s.client.makeRequest(ctx, req, dr); err != nil {
-		return dr, err
-	}
-	return dr, nil
+	return s.resource.Delete(ctx, id)
+}
+
+// DeleteByKey is used to delete an asset identified by key, e.g. planhat.ByExternalID("123").
+func (s *AssetService) DeleteByKey(ctx context.Context, key Key) (*DeleteResponse, error) {
+	return s.resource.DeleteByKey(ctx, key)
 }
 
 // BulkUpsert will update or insert assets.
@@ -149,18 +124,5 @@ func (s *AssetService) Delete(ctx context.Context, id string) (*DeleteResponse,
 // Note there is an upper limit of 50,000 items per request.
 // For more information, see the [planhat docs](https://docs.planhat.com/#bulk_upsert)
 func (s *AssetService) BulkUpsert(ctx context.Context, assets []Asset) (*UpsertResponse, error) {
-	url := fmt.Sprintf("%s/assets", s.client.BaseURL)
-	payload, err := json.Marshal(assets)
-	if err != nil {
-		return nil, err
-	}
-	req, err := http.NewRequest("PUT", url, strings.NewReader(string(payload)))
-	if err != nil {
-		return nil, err
-	}
-	ur := &UpsertResponse{}
-	if err := s.client.makeRequest(ctx, req, ur); err != nil {
-		return ur, err
-	}
-	return ur, nil
+	return s.resource.BulkUpsert(ctx, assets)
 }
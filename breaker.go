@@ -0,0 +1,214 @@
+package planhat
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Endpoint identifies one of the planhat hosts used by the client.
+type Endpoint int
+
+// Endpoints used by the client.
+const (
+	// EndpointAPI is the main planhat API, e.g. https://api.planhat.com.
+	EndpointAPI Endpoint = iota
+	// EndpointMetrics is the analytics host used for pushing metrics.
+	EndpointMetrics
+)
+
+func (e Endpoint) String() string {
+	switch e {
+	case EndpointAPI:
+		return "api"
+	case EndpointMetrics:
+		return "metrics"
+	}
+	return "unknown"
+}
+
+// CircuitState is the state of a circuit breaker.
+type CircuitState int
+
+// Circuit breaker states.
+const (
+	// CircuitClosed allows all requests through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects requests with ErrCircuitOpen.
+	CircuitOpen
+	// CircuitHalfOpen allows a limited number of probe requests through to test recovery.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// CircuitBreakerSettings configures the circuit breakers created by WithCircuitBreaker.
+type CircuitBreakerSettings struct {
+	// FailureThreshold is the number of consecutive failures that opens the circuit.  Defaults to 5.
+	FailureThreshold int
+
+	// OpenTimeout is how long the circuit stays open before allowing probe requests.  Defaults to 30s.
+	OpenTimeout time.Duration
+
+	// HalfOpenProbes is the number of successful probe requests required to close the circuit again,
+	// and the number of probes allowed at once.  Defaults to 1.
+	HalfOpenProbes int
+
+	// OnStateChange, if set, is called whenever a breaker changes state.
+	OnStateChange func(e Endpoint, from, to CircuitState)
+}
+
+// WithCircuitBreaker enables a circuit breaker for each of the main API and the metrics endpoint.
+// Network errors and 5xx responses count as failures.  Once FailureThreshold consecutive failures
+// have occurred, requests to that endpoint fail immediately with ErrCircuitOpen until OpenTimeout
+// has passed, after which probe requests are allowed through to test whether planhat has recovered.
+func WithCircuitBreaker(settings CircuitBreakerSettings) Option {
+	return func(c *Client) error {
+		if settings.FailureThreshold < 0 || settings.OpenTimeout < 0 || settings.HalfOpenProbes < 0 {
+			return errors.New("invalid circuit breaker settings")
+		}
+		if settings.FailureThreshold == 0 {
+			settings.FailureThreshold = 5
+		}
+		if settings.OpenTimeout == 0 {
+			settings.OpenTimeout = 30 * time.Second
+		}
+		if settings.HalfOpenProbes == 0 {
+			settings.HalfOpenProbes = 1
+		}
+		c.breakers = map[Endpoint]*circuitBreaker{
+			EndpointAPI:     {endpoint: EndpointAPI, settings: settings},
+			EndpointMetrics: {endpoint: EndpointMetrics, settings: settings},
+		}
+		return nil
+	}
+}
+
+// CircuitState returns the state of the circuit breaker for the given endpoint, for use in health
+// checks.  If circuit breakers are not enabled it always returns CircuitClosed.
+func (c *Client) CircuitState(e Endpoint) CircuitState {
+	b, ok := c.breakers[e]
+	if !ok {
+		return CircuitClosed
+	}
+	return b.State()
+}
+
+// circuitBreaker tracks failures for a single endpoint.
+type circuitBreaker struct {
+	endpoint Endpoint
+	settings CircuitBreakerSettings
+
+	mu        sync.Mutex
+	state     CircuitState
+	failures  int
+	successes int
+	probes    int
+	openedAt  time.Time
+	changes   [][2]CircuitState // state changes to report once mu is released
+}
+
+// State returns the current state, moving from open to half-open once the timeout has passed.
+func (b *circuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.unlock()
+	b.expire()
+	return b.state
+}
+
+// allow reports whether a request may be sent, returning ErrCircuitOpen if not.
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.unlock()
+	b.expire()
+	switch b.state {
+	case CircuitOpen:
+		return ErrCircuitOpen
+	case CircuitHalfOpen:
+		if b.probes >= b.settings.HalfOpenProbes {
+			return ErrCircuitOpen
+		}
+		b.probes++
+	}
+	return nil
+}
+
+// record updates the breaker with the outcome of a request that allow permitted.
+func (b *circuitBreaker) record(res *http.Response, err error) {
+	// Cancelled requests tell us nothing about the health of planhat.
+	cancelled := errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+	failed := err != nil || res.StatusCode >= http.StatusInternalServerError
+
+	b.mu.Lock()
+	defer b.unlock()
+	if b.state == CircuitHalfOpen {
+		if b.probes > 0 {
+			b.probes--
+		}
+		if cancelled {
+			return
+		}
+		if failed {
+			b.setState(CircuitOpen)
+			return
+		}
+		b.successes++
+		if b.successes >= b.settings.HalfOpenProbes {
+			b.setState(CircuitClosed)
+		}
+		return
+	}
+	if cancelled {
+		return
+	}
+	if !failed {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == CircuitClosed && b.failures >= b.settings.FailureThreshold {
+		b.setState(CircuitOpen)
+	}
+}
+
+// expire moves an open breaker to half-open once the open timeout has passed.  b.mu must be held.
+func (b *circuitBreaker) expire() {
+	if b.state == CircuitOpen && time.Since(b.openedAt) >= b.settings.OpenTimeout {
+		b.setState(CircuitHalfOpen)
+	}
+}
+
+// setState changes the state and resets the counters.  b.mu must be held.
+func (b *circuitBreaker) setState(s CircuitState) {
+	from := b.state
+	b.state = s
+	b.failures, b.successes, b.probes = 0, 0, 0
+	if s == CircuitOpen {
+		b.openedAt = time.Now()
+	}
+	if b.settings.OnStateChange != nil && from != s {
+		b.changes = append(b.changes, [2]CircuitState{from, s})
+	}
+}
+
+// unlock releases b.mu and then reports any state changes.
+func (b *circuitBreaker) unlock() {
+	changes := b.changes
+	b.changes = nil
+	b.mu.Unlock()
+	for _, c := range changes {
+		b.settings.OnStateChange(b.endpoint, c[0], c[1])
+	}
+}
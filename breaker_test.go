@@ -0,0 +1,84 @@
+package planhat
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var healthy atomic.Bool
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	}))
+	defer ts.Close()
+
+	var changes []string
+	c, _ := New("key", WithBaseURL(ts.URL), WithCircuitBreaker(CircuitBreakerSettings{
+		FailureThreshold: 3,
+		OpenTimeout:      20 * time.Millisecond,
+		OnStateChange: func(e Endpoint, from, to CircuitState) {
+			changes = append(changes, fmt.Sprintf("%v:%v->%v", e, from, to))
+		},
+	}))
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.CompanyService.Get(ctx, "1"); err != ErrUnknown {
+			t.Fatalf("got error: %v; want %v", err, ErrUnknown)
+		}
+	}
+	if got := c.CircuitState(EndpointAPI); got != CircuitOpen {
+		t.Fatalf("got state: %v; want %v", got, CircuitOpen)
+	}
+	if got := c.CircuitState(EndpointMetrics); got != CircuitClosed {
+		t.Errorf("metrics breaker should be unaffected, got state: %v", got)
+	}
+	if _, err := c.CompanyService.Get(ctx, "1"); err != ErrCircuitOpen {
+		t.Errorf("got error: %v; want %v", err, ErrCircuitOpen)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("got %d calls; want 3", calls.Load())
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	if got := c.CircuitState(EndpointAPI); got != CircuitHalfOpen {
+		t.Fatalf("got state: %v; want %v", got, CircuitHalfOpen)
+	}
+	// A failed probe opens the circuit again.
+	c.CompanyService.Get(ctx, "1")
+	if got := c.CircuitState(EndpointAPI); got != CircuitOpen {
+		t.Fatalf("got state: %v; want %v", got, CircuitOpen)
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	healthy.Store(true)
+	if _, err := c.CompanyService.Get(ctx, "1"); err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+	if got := c.CircuitState(EndpointAPI); got != CircuitClosed {
+		t.Errorf("got state: %v; want %v", got, CircuitClosed)
+	}
+
+	want := []string{"api:closed->open", "api:open->half-open", "api:half-open->open", "api:open->half-open", "api:half-open->closed"}
+	if fmt.Sprint(changes) != fmt.Sprint(want) {
+		t.Errorf("got changes: %v; want %v", changes, want)
+	}
+}
+
+func TestCircuitBreaker_Disabled(t *testing.T) {
+	c, _ := New("key")
+	if got := c.CircuitState(EndpointAPI); got != CircuitClosed {
+		t.Errorf("got state: %v; want %v", got, CircuitClosed)
+	}
+}
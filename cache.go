@@ -0,0 +1,383 @@
+package planhat
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// CacheOptions configures a CompanyCache.
+type CacheOptions struct {
+	// TTL is how long a company is cached for.  Defaults to one minute.
+	TTL time.Duration
+
+	// NegativeTTL is how long ErrNotFound results are cached for.  Zero disables negative caching.
+	NegativeTTL time.Duration
+
+	// MaxEntries is the maximum number of companies cached, after which the least recently used are
+	// evicted.  Defaults to 10,000.
+	MaxEntries int
+}
+
+// CacheStats reports the performance of a cache.
+type CacheStats struct {
+	// Hits is the number of lookups answered from the cache, including negative hits.
+	Hits uint64
+	// NegativeHits is the number of lookups answered with a cached ErrNotFound.
+	NegativeHits uint64
+	// Misses is the number of lookups that had to be sent to planhat.
+	Misses uint64
+	// Evictions is the number of entries removed to stay within MaxEntries.
+	Evictions uint64
+	// Invalidations is the number of entries removed due to writes.
+	Invalidations uint64
+	// Entries is the number of records currently cached.
+	Entries int
+}
+
+// CompanyCache is a read-through cache in front of a CompanyAPI, such as the CompanyService.  Get,
+// GetByKey, GetByExternalID and GetBySourceID are answered from the cache where possible, and a
+// company fetched by one identifier is also cached under its others.  Updates, deletes and bulk
+// upserts made through the cache invalidate the affected companies.  All other methods are passed
+// straight through.
+//
+// Only writes made through the cache are seen, so install it on the client to have every write
+// through that client invalidate it:
+//
+//	cache := planhat.NewCompanyCache(ph.CompanyService, opts)
+//	ph.CompanyService = cache
+//
+// Writes made through another client, a reference to the service kept from before the cache was
+// installed, or elsewhere, such as the planhat UI, are only seen once the cached entries expire.
+//
+// Cached companies are returned as shallow copies, so callers must not modify their maps or slices.
+type CompanyCache struct {
+	CompanyAPI
+	cache *recordCache[Company]
+}
+
+var _ CompanyAPI = (*CompanyCache)(nil)
+
+// NewCompanyCache returns a CompanyCache in front of api.
+func NewCompanyCache(api CompanyAPI, opts CacheOptions) *CompanyCache {
+	return &CompanyCache{CompanyAPI: api, cache: newRecordCache[Company](opts, companyAliases)}
+}
+
+// companyAliases returns the keys a company can be looked up by.
+func companyAliases(co *Company) []string {
+	var keys []string
+	if id := co.GetID(); id != "" {
+		keys = append(keys, ByID(id).String())
+	}
+	if ext := co.GetExternalID(); ext != "" {
+		keys = append(keys, ByExternalID(ext).String())
+	}
+	return keys
+}
+
+// Stats returns the cache statistics.
+func (c *CompanyCache) Stats() CacheStats {
+	return c.cache.stats()
+}
+
+// Invalidate removes the company identified by key from the cache.
+func (c *CompanyCache) Invalidate(key Key) {
+	c.cache.invalidate(key.String())
+}
+
+// Purge removes all companies from the cache.
+func (c *CompanyCache) Purge() {
+	c.cache.purge()
+}
+
+// Get returns a single company given its planhat ID, from the cache if possible.
+func (c *CompanyCache) Get(ctx context.Context, id string) (*Company, error) {
+	return c.cache.get(id, func() (*Company, error) { return c.CompanyAPI.Get(ctx, id) })
+}
+
+// GetByKey retrieves a company identified by key, from the cache if possible.
+func (c *CompanyCache) GetByKey(ctx context.Context, key Key) (*Company, error) {
+	return c.cache.get(key.String(), func() (*Company, error) { return c.CompanyAPI.GetByKey(ctx, key) })
+}
+
+// GetByExternalID retrieves a company using its external ID, from the cache if possible.
+func (c *CompanyCache) GetByExternalID(ctx context.Context, externalID string) (*Company, error) {
+	return c.cache.get(ByExternalID(externalID).String(), func() (*Company, error) {
+		return c.CompanyAPI.GetByExternalID(ctx, externalID)
+	})
+}
+
+// GetBySourceID retrieves a company using its source ID, from the cache if possible.
+func (c *CompanyCache) GetBySourceID(ctx context.Context, sourceID string) (*Company, error) {
+	return c.cache.get(BySourceID(sourceID).String(), func() (*Company, error) {
+		return c.CompanyAPI.GetBySourceID(ctx, sourceID)
+	})
+}
+
+// GetMany retrieves the companies identified by keys, fetching only those that aren't cached.
+func (c *CompanyCache) GetMany(ctx context.Context, keys []Key) []Result[Company] {
+	results := make([]Result[Company], len(keys))
+	var missing []Key
+	var index []int
+	var gens []uint64
+	for i, key := range keys {
+		results[i].Key = key
+		co, gen, ok, err := c.cache.lookup(key.String())
+		if !ok {
+			missing = append(missing, key)
+			index = append(index, i)
+			gens = append(gens, gen)
+			continue
+		}
+		if err == nil {
+			results[i].Value = co
+		}
+		results[i].Err = err
+	}
+	if len(missing) == 0 {
+		return results
+	}
+	for j, res := range c.CompanyAPI.GetMany(ctx, missing) {
+		c.cache.store(gens[j], res.Key.String(), res.Value, res.Err)
+		results[index[j]] = res
+	}
+	return results
+}
+
+// Create creates a new company record, removing any cached ErrNotFound results.
+func (c *CompanyCache) Create(ctx context.Context, company Company) (*Company, error) {
+	defer c.cache.purgeNegative()
+	return c.CompanyAPI.Create(ctx, company)
+}
+
+// Update updates a company and invalidates it in the cache.
+func (c *CompanyCache) Update(ctx context.Context, id string, company Company) (*Company, error) {
+	co, err := c.CompanyAPI.Update(ctx, id, company)
+	c.invalidate(id, &company, co)
+	return co, err
+}
+
+// UpdateByKey updates a company identified by key and invalidates it in the cache.
+func (c *CompanyCache) UpdateByKey(ctx context.Context, key Key, company Company) (*Company, error) {
+	co, err := c.CompanyAPI.UpdateByKey(ctx, key, company)
+	c.invalidate(key.String(), &company, co)
+	return co, err
+}
+
+// UpdateFields applies a partial update to a company identified by key and invalidates it in the
+// cache.
+func (c *CompanyCache) UpdateFields(ctx context.Context, key Key, update *CompanyUpdate) (*Company, error) {
+	co, err := c.CompanyAPI.UpdateFields(ctx, key, update)
+	c.invalidate(key.String(), co)
+	return co, err
+}
+
+// UpdateIfChanged updates the fields of a company that differ and invalidates it in the cache.
+func (c *CompanyCache) UpdateIfChanged(ctx context.Context, key Key, company Company) (*Company, []Change, error) {
+	co, changes, err := c.CompanyAPI.UpdateIfChanged(ctx, key, company)
+	if len(changes) > 0 || err != nil {
+		c.invalidate(key.String(), &company, co)
+	}
+	return co, changes, err
+}
+
+// Delete deletes a company and invalidates it in the cache.
+func (c *CompanyCache) Delete(ctx context.Context, id string) (*DeleteResponse, error) {
+	dr, err := c.CompanyAPI.Delete(ctx, id)
+	c.cache.invalidate(id)
+	return dr, err
+}
+
+// DeleteByKey deletes a company identified by key and invalidates it in the cache.
+func (c *CompanyCache) DeleteByKey(ctx context.Context, key Key) (*DeleteResponse, error) {
+	dr, err := c.CompanyAPI.DeleteByKey(ctx, key)
+	c.cache.invalidate(key.String())
+	return dr, err
+}
+
+// BulkUpsert updates or inserts companies, invalidating any that are cached along with any cached
+// ErrNotFound results.
+func (c *CompanyCache) BulkUpsert(ctx context.Context, companies []Company) (*UpsertResponse, error) {
+	ur, err := c.CompanyAPI.BulkUpsert(ctx, companies)
+	for i := range companies {
+		c.invalidate("", &companies[i], nil)
+	}
+	if ur != nil {
+		for _, id := range ur.UpsertedIDs {
+			c.cache.invalidate(id)
+		}
+	}
+	c.cache.purgeNegative()
+	return ur, err
+}
+
+// invalidate removes the company stored under key, and any stored under the identifiers of the
+// companies provided.
+func (c *CompanyCache) invalidate(key string, companies ...*Company) {
+	if key != "" {
+		c.cache.invalidate(key)
+	}
+	for _, co := range companies {
+		if co == nil {
+			continue
+		}
+		for _, k := range companyAliases(co) {
+			c.cache.invalidate(k)
+		}
+	}
+}
+
+// recordCache is an LRU cache of records with expiry, where each record may be stored under several
+// keys.
+type recordCache[T any] struct {
+	mu      sync.Mutex
+	opts    CacheOptions
+	aliases func(*T) []string
+	lru     *list.List // of *cacheEntry[T], most recently used at the front
+	keys    map[string]*list.Element
+	gen     uint64 // incremented by invalidations, so fetches racing them aren't cached
+	st      CacheStats
+}
+
+type cacheEntry[T any] struct {
+	keys    []string
+	record  *T
+	err     error
+	expires time.Time
+}
+
+func newRecordCache[T any](opts CacheOptions, aliases func(*T) []string) *recordCache[T] {
+	if opts.TTL <= 0 {
+		opts.TTL = time.Minute
+	}
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = 10000
+	}
+	return &recordCache[T]{
+		opts:    opts,
+		aliases: aliases,
+		lru:     list.New(),
+		keys:    map[string]*list.Element{},
+	}
+}
+
+// get returns the record stored under key, calling fetch and caching the result on a miss.
+func (c *recordCache[T]) get(key string, fetch func() (*T, error)) (*T, error) {
+	record, gen, ok, err := c.lookup(key)
+	if ok {
+		return record, err
+	}
+	record, err = fetch()
+	c.store(gen, key, record, err)
+	return record, err
+}
+
+// lookup returns the record or error stored under key and whether it was found.  On a miss it
+// returns the generation to pass to store.
+func (c *recordCache[T]) lookup(key string) (record *T, gen uint64, ok bool, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.keys[key]; ok {
+		e := el.Value.(*cacheEntry[T])
+		if time.Now().Before(e.expires) {
+			c.lru.MoveToFront(el)
+			c.st.Hits++
+			if e.err != nil {
+				c.st.NegativeHits++
+				return new(T), 0, true, e.err
+			}
+			v := *e.record
+			return &v, 0, true, nil
+		}
+		c.remove(el)
+	}
+	c.st.Misses++
+	return nil, c.gen, false, nil
+}
+
+// store caches the result of fetching key, if it is a record or ErrNotFound.
+func (c *recordCache[T]) store(gen uint64, key string, record *T, err error) {
+	switch {
+	case err == nil && record != nil:
+		keys := append([]string{key}, c.aliases(record)...)
+		v := *record
+		c.add(gen, &cacheEntry[T]{keys: keys, record: &v, expires: time.Now().Add(c.opts.TTL)})
+	case errors.Is(err, ErrNotFound) && c.opts.NegativeTTL > 0:
+		c.add(gen, &cacheEntry[T]{keys: []string{key}, err: err, expires: time.Now().Add(c.opts.NegativeTTL)})
+	}
+}
+
+// add stores an entry under all of its keys, replacing any existing entries for them.  The entry is
+// dropped if the cache has been invalidated since gen was read.
+func (c *recordCache[T]) add(gen uint64, e *cacheEntry[T]) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		return
+	}
+	for _, k := range e.keys {
+		if el, ok := c.keys[k]; ok {
+			c.remove(el)
+		}
+	}
+	el := c.lru.PushFront(e)
+	for _, k := range e.keys {
+		c.keys[k] = el
+	}
+	for c.lru.Len() > c.opts.MaxEntries {
+		c.remove(c.lru.Back())
+		c.st.Evictions++
+	}
+}
+
+// remove deletes an entry and all of its keys.  c.mu must be held.
+func (c *recordCache[T]) remove(el *list.Element) {
+	e := el.Value.(*cacheEntry[T])
+	for _, k := range e.keys {
+		if c.keys[k] == el {
+			delete(c.keys, k)
+		}
+	}
+	c.lru.Remove(el)
+}
+
+func (c *recordCache[T]) invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	if el, ok := c.keys[key]; ok {
+		c.remove(el)
+		c.st.Invalidations++
+	}
+}
+
+func (c *recordCache[T]) purgeNegative() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	for el := c.lru.Front(); el != nil; {
+		next := el.Next()
+		if el.Value.(*cacheEntry[T]).err != nil {
+			c.remove(el)
+		}
+		el = next
+	}
+}
+
+func (c *recordCache[T]) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	c.lru.Init()
+	c.keys = map[string]*list.Element{}
+}
+
+func (c *recordCache[T]) stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st := c.st
+	st.Entries = c.lru.Len()
+	return st
+}
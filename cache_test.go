@@ -0,0 +1,187 @@
+package planhat
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeCompanies serves companies from a map keyed by id and extid- keyables, counting Get calls.
+type fakeCompanies struct {
+	CompanyAPI
+	companies map[string]*Company
+	gets      int
+}
+
+func (f *fakeCompanies) GetByKey(ctx context.Context, key Key) (*Company, error) {
+	f.gets++
+	if co, ok := f.companies[key.String()]; ok {
+		c := *co
+		return &c, nil
+	}
+	return &Company{}, ErrNotFound
+}
+
+func (f *fakeCompanies) Get(ctx context.Context, id string) (*Company, error) {
+	return f.GetByKey(ctx, ByID(id))
+}
+
+func (f *fakeCompanies) GetByExternalID(ctx context.Context, externalID string) (*Company, error) {
+	return f.GetByKey(ctx, ByExternalID(externalID))
+}
+
+func (f *fakeCompanies) GetMany(ctx context.Context, keys []Key) []Result[Company] {
+	results := make([]Result[Company], len(keys))
+	for i, key := range keys {
+		results[i].Key = key
+		results[i].Value, results[i].Err = f.GetByKey(ctx, key)
+	}
+	return results
+}
+
+func (f *fakeCompanies) Update(ctx context.Context, id string, company Company) (*Company, error) {
+	co := f.companies[id]
+	co.Name = company.Name
+	return co, nil
+}
+
+func (f *fakeCompanies) BulkUpsert(ctx context.Context, companies []Company) (*UpsertResponse, error) {
+	for i := range companies {
+		co := companies[i]
+		f.companies["extid-"+co.GetExternalID()] = &co
+	}
+	return &UpsertResponse{}, nil
+}
+
+func TestCompanyCache(t *testing.T) {
+	ctx := context.Background()
+	acme := &Company{ID: String("1"), ExternalID: String("a/1"), Name: String("Acme")}
+	f := &fakeCompanies{companies: map[string]*Company{"1": acme, "extid-a/1": acme}}
+	c := NewCompanyCache(f, CacheOptions{NegativeTTL: time.Minute})
+
+	if co, err := c.Get(ctx, "1"); err != nil || co.GetName() != "Acme" {
+		t.Fatalf("Get = %v, %v", co, err)
+	}
+	// Cached under its external ID too.
+	if co, err := c.GetByExternalID(ctx, "a/1"); err != nil || co.GetName() != "Acme" {
+		t.Fatalf("GetByExternalID = %v, %v", co, err)
+	}
+	if f.gets != 1 {
+		t.Errorf("gets = %d, want 1", f.gets)
+	}
+
+	// Updating by id invalidates the externalId alias.
+	if _, err := c.Update(ctx, "1", Company{Name: String("Acme Ltd")}); err != nil {
+		t.Fatal(err)
+	}
+	if co, _ := c.GetByKey(ctx, ByExternalID("a/1")); co.GetName() != "Acme Ltd" {
+		t.Errorf("name after update = %q", co.GetName())
+	}
+	if f.gets != 2 {
+		t.Errorf("gets = %d, want 2", f.gets)
+	}
+
+	// Not found is cached until a bulk upsert.
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetByExternalID(ctx, "b"); err != ErrNotFound {
+			t.Fatalf("err = %v, want ErrNotFound", err)
+		}
+	}
+	if _, err := c.BulkUpsert(ctx, []Company{{ExternalID: String("b"), Name: String("Beta")}}); err != nil {
+		t.Fatal(err)
+	}
+	if co, err := c.GetByExternalID(ctx, "b"); err != nil || co.GetName() != "Beta" {
+		t.Errorf("GetByExternalID after upsert = %v, %v", co, err)
+	}
+
+	st := c.Stats()
+	want := CacheStats{Hits: 2, NegativeHits: 1, Misses: 4, Invalidations: 2, Entries: 2}
+	if st != want {
+		t.Errorf("Stats = %+v, want %+v", st, want)
+	}
+}
+
+func TestCompanyCacheEviction(t *testing.T) {
+	ctx := context.Background()
+	f := &fakeCompanies{companies: map[string]*Company{}}
+	for _, id := range []string{"1", "2", "3"} {
+		f.companies[id] = &Company{ID: String(id)}
+	}
+	c := NewCompanyCache(f, CacheOptions{MaxEntries: 2})
+	c.Get(ctx, "1")
+	c.Get(ctx, "2")
+	c.Get(ctx, "1") // 2 is now least recently used
+	c.Get(ctx, "3")
+	c.Get(ctx, "1")
+	c.Get(ctx, "2")
+	if f.gets != 4 {
+		t.Errorf("gets = %d, want 4", f.gets)
+	}
+	if st := c.Stats(); st.Evictions != 2 || st.Entries != 2 {
+		t.Errorf("Stats = %+v", st)
+	}
+}
+
+func TestCompanyCacheExpiry(t *testing.T) {
+	ctx := context.Background()
+	f := &fakeCompanies{companies: map[string]*Company{"1": {ID: String("1")}}}
+	c := NewCompanyCache(f, CacheOptions{TTL: time.Millisecond})
+	c.Get(ctx, "1")
+	time.Sleep(5 * time.Millisecond)
+	c.Get(ctx, "1")
+	if f.gets != 2 {
+		t.Errorf("gets = %d, want 2", f.gets)
+	}
+}
+
+func TestCompanyCacheGetMany(t *testing.T) {
+	ctx := context.Background()
+	f := &fakeCompanies{companies: map[string]*Company{
+		"1":       {ID: String("1"), ExternalID: String("a")},
+		"extid-a": {ID: String("1"), ExternalID: String("a")},
+		"2":       {ID: String("2")},
+	}}
+	c := NewCompanyCache(f, CacheOptions{NegativeTTL: time.Minute})
+	c.Get(ctx, "1")
+
+	keys := []Key{ByExternalID("a"), ByID("2"), ByID("3")}
+	results := c.GetMany(ctx, keys)
+	if results[0].Err != nil || results[0].Value.GetID() != "1" || results[1].Value.GetID() != "2" || results[2].Err != ErrNotFound {
+		t.Fatalf("GetMany = %+v", results)
+	}
+	if f.gets != 3 {
+		t.Errorf("gets = %d, want 3", f.gets)
+	}
+	c.GetMany(ctx, keys)
+	if f.gets != 3 {
+		t.Errorf("gets after second GetMany = %d, want 3", f.gets)
+	}
+}
+
+func TestCompanyCacheOnClient(t *testing.T) {
+	name, gets := "Acme", 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			name = "Acme Ltd"
+		} else {
+			gets++
+		}
+		fmt.Fprintf(w, `{"_id":"1","externalId":"acme","name":%q}`, name)
+	}))
+	defer ts.Close()
+	ctx := context.Background()
+
+	ph, _ := New("key", WithBaseURL(ts.URL))
+	ph.CompanyService = NewCompanyCache(ph.CompanyService, CacheOptions{})
+	ph.CompanyService.GetByExternalID(ctx, "acme")
+	if _, err := ph.CompanyService.Update(ctx, "1", Company{Name: String("Acme Ltd")}); err != nil {
+		t.Fatal(err)
+	}
+	co, _ := ph.CompanyService.GetByExternalID(ctx, "acme")
+	if co.GetName() != "Acme Ltd" || gets != 2 {
+		t.Errorf("got %q after %d gets; want the updated name after 2", co.GetName(), gets)
+	}
+}
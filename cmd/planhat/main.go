@@ -0,0 +1,146 @@
+// Command planhat provides command line tools for working with planhat.
+//
+// Usage:
+//
+//	planhat import-metrics [flags] file
+//
+// The API key is read from the PLANHAT_API_KEY environment variable and the tenant UUID, required
+// to send metrics, from PLANHAT_TENANT_UUID unless the -tenant flag is given.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/darrenparkinson/planhat"
+)
+
+const usage = `usage: planhat <command> [flags]
+
+commands:
+  import-metrics   import metrics from a CSV or JSONL file
+`
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	err := run(ctx, os.Args[1:], os.Getenv, os.Stdout, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "planhat:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, getenv func(string) string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return flag.ErrHelp
+	}
+	switch args[0] {
+	case "import-metrics":
+		return importMetrics(ctx, args[1:], getenv, stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return nil
+	}
+	fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+	return flag.ErrHelp
+}
+
+func importMetrics(ctx context.Context, args []string, getenv func(string) string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("import-metrics", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: planhat import-metrics [flags] file")
+		fs.PrintDefaults()
+	}
+	var (
+		format    = fs.String("format", "", "input format, csv or jsonl (default from the file extension)")
+		rejects   = fs.String("rejects", "", "file to write rejected rows to (default file.rejects.ext)")
+		tenant    = fs.String("tenant", getenv("PLANHAT_TENANT_UUID"), "tenant UUID")
+		batch     = fs.Int("batch", 5000, "metrics per request")
+		normalise = fs.Bool("normalise", false, "normalise dimension IDs, e.g. \"Active Users\" to \"activeusers\"")
+		dryRun    = fs.Bool("dry-run", false, "print the requests instead of sending them")
+		cols      planhat.MetricColumns
+	)
+	fs.StringVar(&cols.DimensionID, "dimension", "dimensionId", "dimension ID column")
+	fs.StringVar(&cols.Value, "value", "value", "value column")
+	fs.StringVar(&cols.ExternalID, "externalid", "externalId", "external ID column")
+	fs.StringVar(&cols.Model, "model", "model", "model column")
+	fs.StringVar(&cols.Date, "date", "date", "date column")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	path := fs.Arg(0)
+
+	opts := planhat.MetricImportOptions{Columns: cols, BatchSize: *batch, NormaliseDimensions: *normalise}
+	ext := strings.ToLower(filepath.Ext(path))
+	if *format == "" {
+		*format = "csv"
+		if ext == ".jsonl" || ext == ".ndjson" {
+			*format = "jsonl"
+		}
+	}
+	switch *format {
+	case "csv":
+		opts.Format = planhat.ImportCSV
+	case "jsonl":
+		opts.Format = planhat.ImportJSONL
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	clientOpts := []planhat.Option{planhat.WithTenantUUID(*tenant)}
+	if *dryRun {
+		clientOpts = append(clientOpts, planhat.WithDryRun(planhat.NewDryRun(stdout)))
+	}
+	ph, err := planhat.New(getenv("PLANHAT_API_KEY"), clientOpts...)
+	if err != nil {
+		return err
+	}
+
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if *rejects == "" {
+		*rejects = strings.TrimSuffix(path, filepath.Ext(path)) + ".rejects" + filepath.Ext(path)
+	}
+	out, err := os.Create(*rejects)
+	if err != nil {
+		return err
+	}
+	opts.Rejects = out
+
+	res, err := planhat.ImportMetrics(ctx, ph.MetricsService, in, opts)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if res != nil {
+		fmt.Fprintf(stderr, "%d rows: %d imported in %d requests, %d rejected\n", res.Rows, res.Imported, res.Batches, res.Rejected)
+		for _, e := range res.Errors {
+			fmt.Fprintln(stderr, "planhat reported:", e)
+		}
+		if res.Rejected > 0 {
+			fmt.Fprintln(stderr, "rejected rows written to", *rejects)
+		} else {
+			os.Remove(*rejects)
+		}
+	}
+	return err
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImportMetrics(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "usage.csv")
+	os.WriteFile(path, []byte("Metric,Account,Count\nlogins,acme,3\nlogins,,4\n"), 0o600)
+	env := map[string]string{"PLANHAT_API_KEY": "key", "PLANHAT_TENANT_UUID": "tenant"}
+
+	var stdout, stderr strings.Builder
+	args := []string{"import-metrics", "-dry-run", "-dimension", "Metric", "-externalid", "Account", "-value", "Count", path}
+	if err := run(context.Background(), args, func(k string) string { return env[k] }, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), `"body":[{"dimensionId":"logins","value":3,"externalId":"acme"}]`) {
+		t.Errorf("dry run output = %s", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "2 rows: 1 imported in 1 requests, 1 rejected") {
+		t.Errorf("stderr = %s", stderr.String())
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "usage.rejects.csv"))
+	if err != nil || string(b) != "Metric,Account,Count,reason\nlogins,,4,externalId: required\n" {
+		t.Errorf("rejects = %q, %v", b, err)
+	}
+}
+
+func TestUnknownCommand(t *testing.T) {
+	var stdout, stderr strings.Builder
+	if err := run(context.Background(), []string{"export"}, func(string) string { return "" }, &stdout, &stderr); err == nil {
+		t.Error("expected error")
+	}
+}
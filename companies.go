@@ -2,10 +2,8 @@ package planhat
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/http"
-	"strings"
 	"time"
 )
 
@@ -75,99 +73,77 @@ type Company struct {
 
 // Create creates a new company record
 func (s *CompanyService) Create(ctx context.Context, company Company) (*Company, error) {
-	co := &Company{}
-	url := fmt.Sprintf("%s/companies", s.client.BaseURL)
-	payload, err := json.Marshal(company)
-	if err != nil {
-		return co, err
-	}
-	req, err := http.NewRequest("POST", url, strings.NewReader(string(payload)))
-	if err != nil {
-		return co, err
-	}
-	if err := s.client.makeRequest(ctx, req, co); err != nil {
-		return co, err
-	}
-	return co, nil
+	return s.resource.Create(ctx, company)
 }
 
 // Update will update a planhat company.
 // To update a company it is required to pass the company _id in the request.
 // Alternately it is possible to update using the company externalId and/or sourceId adding a prefix and passing one of these keyables as identifiers.
-// e.g. extid-{{externalId}} or srcid-{{sourceId}}
+// e.g. extid-{{externalId}} or srcid-{{sourceId}}, or by using UpdateByKey which will also escape them for you.
 // Note you may get a Bad Request error if you include the ID in the company record
 func (s *CompanyService) Update(ctx context.Context, id string, company Company) (*Company, error) {
-	co := &Company{}
-	url := fmt.Sprintf("%s/companies/%s", s.client.BaseURL, id)
-	payload, err := json.Marshal(company)
-	if err != nil {
-		return co, err
-	}
-	req, err := http.NewRequest("PUT", url, strings.NewReader(string(payload)))
-	if err != nil {
-		return co, err
-	}
-	if err := s.client.makeRequest(ctx, req, co); err != nil {
-		return co, err
-	}
-	return co, nil
+	return s.resource.Update(ctx, id, company)
+}
+
+// UpdateByKey will update a company identified by key, e.g. planhat.ByExternalID("123").
+func (s *CompanyService) UpdateByKey(ctx context.Context, key Key, company Company) (*Company, error) {
+	return s.resource.UpdateByKey(ctx, key, company)
+}
+
+// UpdateFields applies a partial update to a company identified by key, sending only the fields set or
+// cleared in update.  Use it to clear fields, which Update can't do as empty fields are omitted.
+func (s *CompanyService) UpdateFields(ctx context.Context, key Key, update *CompanyUpdate) (*Company, error) {
+	return s.resource.UpdateFields(ctx, key, update)
+}
+
+// UpdateIfChanged fetches a company identified by key and updates only the fields of company that differ,
+// sending nothing if none do.  It returns the company and the changes made.
+func (s *CompanyService) UpdateIfChanged(ctx context.Context, key Key, company Company) (*Company, []Change, error) {
+	return s.resource.UpdateIfChanged(ctx, key, company)
 }
 
 // Get returns a single company given it's planhat ID
 func (s *CompanyService) Get(ctx context.Context, id string) (*Company, error) {
-	co := &Company{}
-	url := fmt.Sprintf("%s/companies/%s", s.client.BaseURL, id)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return co, err
-	}
-	if err := s.client.makeRequest(ctx, req, &co); err != nil {
-		return co, err
-	}
-	return co, nil
+	return s.resource.Get(ctx, id)
+}
+
+// GetByKey retrieves a company identified by key, e.g. planhat.BySourceID("123").
+func (s *CompanyService) GetByKey(ctx context.Context, key Key) (*Company, error) {
+	return s.resource.GetByKey(ctx, key)
 }
 
 // GetByExternalID retrieves a company using it's external ID
 func (s *CompanyService) GetByExternalID(ctx context.Context, externalID string) (*Company, error) {
-	co := &Company{}
-	url := fmt.Sprintf("%s/companies/extid-%s", s.client.BaseURL, externalID)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return co, err
-	}
-	if err := s.client.makeRequest(ctx, req, co); err != nil {
-		return co, err
-	}
-	return co, nil
+	return s.resource.GetByExternalID(ctx, externalID)
 }
 
 // GetBySourceID retrieves a company using it's source ID
 func (s *CompanyService) GetBySourceID(ctx context.Context, sourceID string) (*Company, error) {
-	co := &Company{}
-	url := fmt.Sprintf("%s/companies/srcid-%s", s.client.BaseURL, sourceID)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return co, err
-	}
-	if err := s.client.makeRequest(ctx, req, co); err != nil {
-		return co, err
-	}
-	return co, nil
+	return s.resource.GetBySourceID(ctx, sourceID)
+}
+
+// GetMany retrieves the companies identified by keys concurrently, returning a result for each key in
+// the same order.  A missing company is reported as ErrNotFound in its result.
+func (s *CompanyService) GetMany(ctx context.Context, keys []Key) []Result[Company] {
+	return s.resource.GetMany(ctx, keys)
 }
 
 // List will list companies based on the CompanyListOptions provided
 func (s *CompanyService) List(ctx context.Context, options ...*CompanyListOptions) ([]*Company, error) {
-	cr := []*Company{}
+	return s.resource.List(ctx, options...)
+}
 
-	url := fmt.Sprintf("%s/companies", s.client.BaseURL)
-	for _, option := range options {
-		var err error
-		url, err = addOptions(url, option)
-		if err != nil {
-			return cr, err
-		}
-	}
-	req, err := http.NewRequest("GET", url, nil)
+// ListEach calls fn with each company matching the CompanyListOptions provided, without holding them
+// all in memory.  Returning an error from fn stops the listing and returns that error.
+func (s *CompanyService) ListEach(ctx context.Context, fn func(*Company) error, options ...*CompanyListOptions) error {
+	return s.resource.ListEach(ctx, fn, options...)
+}
+
+// LeanList returns a lightweight list of all companies in Planhat to match against your own ids etc.
+func (s *CompanyService) LeanList(ctx context.Context, options ...*LeanCompanyListOptions) ([]*LeanCompany, error) {
+	ctx = withOperation(ctx, "CompanyService.LeanList")
+	cr := []*LeanCompany{}
+	req, err := s.leanListRequest(options)
 	if err != nil {
 		return cr, err
 	}
@@ -177,58 +153,43 @@ func (s *CompanyService) List(ctx context.Context, options ...*CompanyListOption
 	return cr, nil
 }
 
-// LeanList returns a lightweight list of all companies in Planhat to match against your own ids etc.
-func (s *CompanyService) LeanList(ctx context.Context, options ...*LeanCompanyListOptions) ([]*LeanCompany, error) {
-	cr := []*LeanCompany{}
+// LeanListEach calls fn with each company in the lean list, decoding them one at a time so that memory
+// use doesn't grow with the number of companies.  Returning an error from fn stops the listing and
+// returns that error.
+func (s *CompanyService) LeanListEach(ctx context.Context, fn func(*LeanCompany) error, options ...*LeanCompanyListOptions) error {
+	ctx = withOperation(ctx, "CompanyService.LeanListEach")
+	req, err := s.leanListRequest(options)
+	if err != nil {
+		return err
+	}
+	return s.client.makeRequest(ctx, req, arrayStream[LeanCompany]{fn: fn})
+}
 
+func (s *CompanyService) leanListRequest(options []*LeanCompanyListOptions) (*http.Request, error) {
 	url := fmt.Sprintf("%s/leancompanies", s.client.BaseURL)
 	for _, option := range options {
 		var err error
 		url, err = addOptions(url, option)
 		if err != nil {
-			return cr, err
+			return nil, err
 		}
 	}
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return cr, err
-	}
-	if err := s.client.makeRequest(ctx, req, &cr); err != nil {
-		return cr, err
-	}
-	return cr, nil
+	return http.NewRequest("GET", url, nil)
 }
 
-// Delete is used delete a company. It is required to pass the _id (ID).
+// Delete is used delete a company using its _id (ID).  Use DeleteByKey to delete using its externalId or sourceId.
 func (s *CompanyService) Delete(ctx context.Context, id string) (*DeleteResponse, error) {
-	url := fmt.Sprintf("%s/companies/%s", s.client.BaseURL, id)
-	req, err := http.NewRequest("DELETE", url, nil)
-	if err != nil {
-		return nil, err
-	}
-	dr := &DeleteResponse{}
-	if err := s.client.makeRequest(ctx, req, dr); err != nil {
-		return dr, err
-	}
-	return dr, nil
+	return s.resource.Delete(ctx, id)
+}
+
+// DeleteByKey is used to delete a company identified by key, e.g. planhat.ByExternalID("123").
+func (s *CompanyService) DeleteByKey(ctx context.Context, key Key) (*DeleteResponse, error) {
+	return s.resource.DeleteByKey(ctx, key)
 }
 
 // BulkUpsert will update or insert companies.
 // Note there is an upper limit of 50,000 items per request.
 // For more information, see the [planhat docs](https://docs.planhat.com/#bulk_upsert)
 func (s *CompanyService) BulkUpsert(ctx context.Context, companies []Company) (*UpsertResponse, error) {
-	url := fmt.Sprintf("%s/companies", s.client.BaseURL)
-	payload, err := json.Marshal(companies)
-	if err != nil {
-		return nil, err
-	}
-	req, err := http.NewRequest("PUT", url, strings.NewReader(string(payload)))
-	if err != nil {
-		return nil, err
-	}
-	ur := &UpsertResponse{}
-	if err := s.client.makeRequest(ctx, req, ur); err != nil {
-		return ur, err
-	}
-	return ur, nil
+	return s.resource.BulkUpsert(ctx, companies)
 }
@@ -0,0 +1,102 @@
+package planhat
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// WithCompression gzips request bodies of at least threshold bytes, such as large bulk upserts, and
+// asks planhat to gzip its responses.  A threshold of 0 compresses every request with a body.
+//
+// Compressed bodies are streamed to the server as they are compressed rather than being held in
+// memory a second time, and are compressed again if the request is retried.
+func WithCompression(threshold int) Option {
+	return func(c *Client) error {
+		if threshold < 0 {
+			return errors.New("compression threshold must not be negative")
+		}
+		c.compress = true
+		c.compressMin = threshold
+		return nil
+	}
+}
+
+// newGzipRequest returns a request whose body is payload, gzipped as it is read.
+func newGzipRequest(method, url string, payload []byte) (*http.Request, error) {
+	getBody := func() (io.ReadCloser, error) { return &gzipBody{payload: payload}, nil }
+	body, _ := getBody()
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.GetBody = getBody
+	req.ContentLength = -1
+	req.Header.Set("Content-Encoding", "gzip")
+	return req, nil
+}
+
+// gzipBody compresses payload through a pipe as it is read.  The compressing goroutine is only
+// started on the first Read, so a body that is never sent doesn't leak it.
+type gzipBody struct {
+	payload []byte
+	pr      *io.PipeReader
+}
+
+func (b *gzipBody) Read(p []byte) (int, error) {
+	if b.pr == nil {
+		var pw *io.PipeWriter
+		b.pr, pw = io.Pipe()
+		go func() {
+			zw := gzip.NewWriter(pw)
+			_, err := zw.Write(b.payload)
+			if err == nil {
+				err = zw.Close()
+			}
+			pw.CloseWithError(err)
+		}()
+	}
+	return b.pr.Read(p)
+}
+
+func (b *gzipBody) Close() error {
+	if b.pr != nil {
+		return b.pr.Close()
+	}
+	return nil
+}
+
+// decompress replaces the body of a gzipped response with its decompressed contents.  It is needed
+// as the transport only decompresses responses itself when it set Accept-Encoding.
+func decompress(res *http.Response) {
+	if res == nil || res.Uncompressed || res.Header.Get("Content-Encoding") != "gzip" {
+		return
+	}
+	res.Body = &gunzipBody{body: res.Body}
+	res.Header.Del("Content-Encoding")
+	res.Header.Del("Content-Length")
+	res.ContentLength = -1
+	res.Uncompressed = true
+}
+
+// gunzipBody decompresses a response body, reading the gzip header on the first Read.
+type gunzipBody struct {
+	body io.ReadCloser
+	zr   *gzip.Reader
+	err  error
+}
+
+func (b *gunzipBody) Read(p []byte) (int, error) {
+	if b.zr == nil && b.err == nil {
+		b.zr, b.err = gzip.NewReader(b.body)
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.zr.Read(p)
+}
+
+func (b *gunzipBody) Close() error {
+	return b.body.Close()
+}
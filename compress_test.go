@@ -0,0 +1,85 @@
+package planhat
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCompression(t *testing.T) {
+	attempts := 0
+	var gotEncodings []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		gotEncodings = append(gotEncodings, r.Header.Get("Content-Encoding"))
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			t.Errorf("Accept-Encoding = %q", r.Header.Get("Accept-Encoding"))
+		}
+		var body io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body = zr
+		}
+		var companies []Company
+		if err := json.NewDecoder(body).Decode(&companies); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		if r.Method == "PUT" && attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		fmt.Fprintf(zw, `{"created":%d}`, len(companies))
+		zw.Close()
+	}))
+	defer ts.Close()
+
+	c, err := New("key", WithBaseURL(ts.URL), WithMetricsURL(ts.URL), WithTenantUUID("t"), WithCompression(1024),
+		WithRetry(1, time.Millisecond, time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	companies := make([]Company, 500)
+	for i := range companies {
+		companies[i] = Company{Name: String(fmt.Sprintf("Company %d", i))}
+	}
+	ur, err := c.CompanyService.BulkUpsert(ctx, companies)
+	if err != nil || ur.Created != 500 {
+		t.Fatalf("BulkUpsert = %+v, %v", ur, err)
+	}
+	if attempts != 2 {
+		t.Errorf("attempts = %d, want 2", attempts)
+	}
+
+	// Below the threshold the body is sent as it is.
+	if _, err := c.CompanyService.BulkUpsert(ctx, companies[:1]); err != nil {
+		t.Fatal(err)
+	}
+	metrics := make([]Metric, 100)
+	for i := range metrics {
+		metrics[i] = Metric{DimensionID: String("logins"), Value: Float64(1), ExternalID: String(fmt.Sprint(i))}
+	}
+	if _, err := c.MetricsService.BulkUpsert(ctx, metrics); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"gzip", "gzip", "", "gzip"}
+	if fmt.Sprint(gotEncodings) != fmt.Sprint(want) {
+		t.Errorf("Content-Encoding = %q, want %q", gotEncodings, want)
+	}
+
+	if _, err := New("key", WithCompression(-1)); err == nil {
+		t.Error("expected error for negative threshold")
+	}
+}
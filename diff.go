@@ -0,0 +1,134 @@
+package planhat
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Change describes a field that differs between two records.
+type Change struct {
+	// Field is the JSON name of the field, e.g. "phase", or "custom." followed by the name of a
+	// custom field.
+	Field string
+	// From is the current value, or nil if it isn't set.
+	From interface{}
+	// To is the desired value.
+	To interface{}
+}
+
+// Diff returns the fields that would change if current were updated with desired, such as a remote
+// and a local Company, sorted by field.  It works with any model whose fields have JSON tags.
+//
+// As with Update, fields that are nil in desired are left alone rather than treated as cleared, and
+// custom fields are compared individually, so only those present in desired are considered.  Values
+// are compared by their JSON encoding, so 5 and 5.0, or the same time in different locations, are
+// equal.
+func Diff[T any](current, desired *T) []Change {
+	var changes []Change
+	if desired == nil {
+		return changes
+	}
+	cv := reflect.ValueOf(current)
+	dv := reflect.ValueOf(desired).Elem()
+	for name, f := range jsonFields(dv.Type()) {
+		d := dv.FieldByIndex(f.Index)
+		if isEmpty(d) {
+			continue
+		}
+		var c reflect.Value
+		if current != nil {
+			c = cv.Elem().FieldByIndex(f.Index)
+		}
+		if name == "custom" && d.Kind() == reflect.Map {
+			changes = append(changes, diffCustom(c, d)...)
+			continue
+		}
+		from, to := value(c), value(d)
+		if !jsonEqual(from, to) {
+			changes = append(changes, Change{Field: name, From: from, To: to})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+func diffCustom(current, desired reflect.Value) []Change {
+	var changes []Change
+	iter := desired.MapRange()
+	for iter.Next() {
+		var from interface{}
+		if current.IsValid() && !current.IsNil() {
+			if v := current.MapIndex(iter.Key()); v.IsValid() {
+				from = v.Interface()
+			}
+		}
+		to := iter.Value().Interface()
+		if !jsonEqual(from, to) {
+			changes = append(changes, Change{Field: "custom." + iter.Key().String(), From: from, To: to})
+		}
+	}
+	return changes
+}
+
+// patchFromChanges returns a partial update of a model of type t applying changes.
+func patchFromChanges(t reflect.Type, changes []Change) *fieldUpdate {
+	u := newFieldUpdate(t)
+	for _, c := range changes {
+		if name, ok := strings.CutPrefix(c.Field, "custom."); ok {
+			u.setCustom(name, c.To)
+		} else {
+			u.set(c.Field, c.To)
+		}
+	}
+	return &u
+}
+
+// isEmpty reports whether a field is unset, i.e. a nil pointer, map, slice or interface.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// value returns the value of a field with any pointer removed, or nil if it is unset.
+func value(v reflect.Value) interface{} {
+	if !v.IsValid() || isEmpty(v) {
+		return nil
+	}
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	return v.Interface()
+}
+
+// jsonEqual reports whether a and b have the same JSON encoding, once decoded.
+func jsonEqual(a, b interface{}) bool {
+	return reflect.DeepEqual(normalise(a), normalise(b))
+}
+
+// normalise returns v as it would be decoded from JSON, with times, which planhat returns as strings
+// in custom fields, converted to UTC so that they compare as instants.
+func normalise(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return v
+	}
+	if s, ok := out.(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return t.UTC().Format(time.RFC3339Nano)
+		}
+	}
+	return out
+}
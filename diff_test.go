@@ -0,0 +1,78 @@
+package planhat
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	renewal := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	localRenewal := renewal.In(time.FixedZone("CET", 3600))
+	remote := &Company{
+		Name:        String("Acme"),
+		Phase:       String("Onboarding"),
+		MRR:         Float64(100),
+		RenewalDate: &renewal,
+		Custom:      map[string]interface{}{"Tier": "Gold", "Seats": 10.0, "Since": "2020-01-01T00:00:00.000Z"},
+	}
+	local := &Company{
+		Name:        String("Acme"),
+		Phase:       String("Live"),
+		MRR:         Float64(100),
+		RenewalDate: &localRenewal,
+		Status:      String("customer"),
+		Custom: map[string]interface{}{
+			"Tier":   "Gold",
+			"Seats":  12,
+			"Since":  time.Date(2020, 1, 1, 1, 0, 0, 0, time.FixedZone("CET", 3600)),
+			"Region": "EU",
+		},
+	}
+	got := fmt.Sprint(Diff(remote, local))
+	want := "[{custom.Region <nil> EU} {custom.Seats 10 12} {phase Onboarding Live} {status <nil> customer}]"
+	if got != want {
+		t.Errorf("Diff = %s, want %s", got, want)
+	}
+	if changes := Diff(remote, remote); len(changes) != 0 {
+		t.Errorf("Diff of the same record = %v", changes)
+	}
+	if got := fmt.Sprint(Diff(nil, &Asset{Name: String("a")})); got != "[{name <nil> a}]" {
+		t.Errorf("Diff from nil = %s", got)
+	}
+}
+
+func TestUpdateIfChanged(t *testing.T) {
+	var got []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = append(got, fmt.Sprintf("%s %s %s", r.Method, r.URL.EscapedPath(), body))
+		fmt.Fprint(w, `{"_id":"1","name":"Acme","phase":"Onboarding","custom":{"Tier":"Gold"}}`)
+	}))
+	defer ts.Close()
+
+	c, _ := New("key", WithBaseURL(ts.URL))
+	ctx := context.Background()
+
+	co, changes, err := c.CompanyService.UpdateIfChanged(ctx, ByExternalID("acme"), Company{Name: String("Acme"), Custom: map[string]interface{}{"Tier": "Gold"}})
+	if err != nil || len(changes) != 0 || co.GetID() != "1" {
+		t.Fatalf("UpdateIfChanged = %+v, %v, %v", co, changes, err)
+	}
+	_, changes, err = c.CompanyService.UpdateIfChanged(ctx, ByExternalID("acme"), Company{Phase: String("Live"), Custom: map[string]interface{}{"Tier": "Silver"}})
+	if err != nil || len(changes) != 2 {
+		t.Fatalf("UpdateIfChanged = %v, %v", changes, err)
+	}
+
+	want := []string{
+		`GET /companies/extid-acme `,
+		`GET /companies/extid-acme `,
+		`PUT /companies/extid-acme {"custom":{"Tier":"Silver"},"phase":"Live"}`,
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got requests:\n%q\nwant:\n%q", got, want)
+	}
+}
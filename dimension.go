@@ -0,0 +1,179 @@
+package planhat
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ValidDimensionID reports whether id is a valid metric dimension ID: a non-empty string of letters,
+// digits and underscores.
+func ValidDimensionID(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, r := range id {
+		if !isDimensionRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// NormaliseDimensionID returns id as a valid dimension ID, lower case with spaces and special
+// characters removed, e.g. "Share of Active Users" becomes "shareofactiveusers".
+func NormaliseDimensionID(id string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(id) {
+		if isDimensionRune(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func isDimensionRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_'
+}
+
+// WithDimensionNormalisation normalises the DimensionID of each metric sent by
+// MetricsService.BulkUpsert using NormaliseDimensionID.  The metrics passed in are not modified.
+func WithDimensionNormalisation(enabled bool) Option {
+	return func(c *Client) error {
+		c.normaliseDimensions = enabled
+		return nil
+	}
+}
+
+// normaliseDimensions returns a copy of metrics with their dimension IDs normalised.
+func normaliseDimensions(metrics []Metric) []Metric {
+	out := make([]Metric, len(metrics))
+	for i, m := range metrics {
+		if m.DimensionID != nil {
+			m.DimensionID = String(NormaliseDimensionID(*m.DimensionID))
+		}
+		out[i] = m
+	}
+	return out
+}
+
+// normalisesDimensions reports whether api normalises dimension IDs before validating them, so that
+// metrics bound for it can be treated the same way.
+func normalisesDimensions(api MetricsAPI) bool {
+	switch api := api.(type) {
+	case *MetricsService:
+		return api.client.normaliseDimensions
+	case *MetricSpool:
+		return api.opts.NormaliseDimensions
+	}
+	return false
+}
+
+// WithDimensionRegistry checks the DimensionID of each metric sent by MetricsService.BulkUpsert
+// against r as part of validation, so that typos are reported before they create new dimensions.
+func WithDimensionRegistry(r *DimensionRegistry) Option {
+	return func(c *Client) error {
+		c.dimensions = r
+		return nil
+	}
+}
+
+// DimensionRegistry holds the known metric dimension IDs.  A dimension ID that isn't known but is
+// close to one that is, such as "logns" for "logins", is rejected as a likely typo.  In strict mode
+// every unknown dimension ID is rejected.  It is safe for concurrent use.
+type DimensionRegistry struct {
+	mu     sync.RWMutex
+	ids    map[string]bool
+	strict bool
+}
+
+// NewDimensionRegistry returns a registry of the dimension IDs given.
+func NewDimensionRegistry(strict bool, ids ...string) *DimensionRegistry {
+	r := &DimensionRegistry{ids: map[string]bool{}, strict: strict}
+	r.Register(ids...)
+	return r
+}
+
+// Register adds dimension IDs to the registry.
+func (r *DimensionRegistry) Register(ids ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range ids {
+		r.ids[id] = true
+	}
+}
+
+// Known reports whether id is in the registry.
+func (r *DimensionRegistry) Known(id string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.ids[id]
+}
+
+// IDs returns the registered dimension IDs, sorted.
+func (r *DimensionRegistry) IDs() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ids := make([]string, 0, len(r.ids))
+	for id := range r.ids {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Suggest returns the known dimension ID closest to id, if one is close enough to suggest that id is
+// a typo of it.
+func (r *DimensionRegistry) Suggest(id string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	best, bestDist := "", -1
+	for known := range r.ids {
+		d := editDistance(strings.ToLower(id), strings.ToLower(known))
+		if bestDist < 0 || d < bestDist || d == bestDist && known < best {
+			best, bestDist = known, d
+		}
+	}
+	// Allow one edit for short IDs and up to a quarter of the length for longer ones.
+	if bestDist < 0 || bestDist > max(1, len(id)/4) {
+		return "", false
+	}
+	return best, true
+}
+
+// Check returns an error if id is unknown and either looks like a typo of a known ID or the registry
+// is strict.
+func (r *DimensionRegistry) Check(id string) error {
+	if r.Known(id) {
+		return nil
+	}
+	if s, ok := r.Suggest(id); ok {
+		return fmt.Errorf("unknown dimension %q, did you mean %q?", id, s)
+	}
+	if r.strict {
+		return fmt.Errorf("unknown dimension %q", id)
+	}
+	return nil
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
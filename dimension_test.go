@@ -0,0 +1,85 @@
+package planhat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDimensionIDs(t *testing.T) {
+	for id, want := range map[string]bool{"logins": true, "active_users_30d": true, "": false, "active users": false, "share%": false, "café": false} {
+		if got := ValidDimensionID(id); got != want {
+			t.Errorf("ValidDimensionID(%q) = %v, want %v", id, got, want)
+		}
+	}
+	for in, want := range map[string]string{"Share of Active Users": "shareofactiveusers", "NPS (30d)": "nps30d", "logins": "logins", "seats_used": "seats_used"} {
+		if got := NormaliseDimensionID(in); got != want {
+			t.Errorf("NormaliseDimensionID(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestDimensionRegistry(t *testing.T) {
+	r := NewDimensionRegistry(false, "logins", "activeusershare", "seats")
+	tests := []struct {
+		id, want string
+	}{
+		{"logins", ""},
+		{"logns", `unknown dimension "logns", did you mean "logins"?`},
+		{"Logins", `unknown dimension "Logins", did you mean "logins"?`},
+		{"activeusersshare", `unknown dimension "activeusersshare", did you mean "activeusershare"?`},
+		{"revenue", ""},
+	}
+	for _, tt := range tests {
+		err := r.Check(tt.id)
+		if got := fmt.Sprint(err); err == nil && tt.want != "" || err != nil && got != tt.want {
+			t.Errorf("Check(%q) = %v, want %q", tt.id, err, tt.want)
+		}
+	}
+	strict := NewDimensionRegistry(true, r.IDs()...)
+	if err := strict.Check("revenue"); err == nil {
+		t.Error("strict registry accepted unknown dimension")
+	}
+	strict.Register("revenue")
+	if err := strict.Check("revenue"); err != nil {
+		t.Errorf("Check after Register = %v", err)
+	}
+}
+
+func TestBulkUpsertDimensions(t *testing.T) {
+	var got []Metric
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprintf(w, `{"processed":%d}`, len(got))
+	}))
+	defer ts.Close()
+	ctx := context.Background()
+
+	registry := NewDimensionRegistry(false, "shareofactiveusers", "logins")
+	c, _ := New("key", WithMetricsURL(ts.URL), WithTenantUUID("t"), WithDimensionNormalisation(true), WithDimensionRegistry(registry))
+	metrics := []Metric{
+		{DimensionID: String("Share of Active Users"), Value: Float64(0.5), ExternalID: String("a")},
+		{DimensionID: String("logins"), Value: Float64(3), ExternalID: String("a")},
+	}
+	if _, err := c.MetricsService.BulkUpsert(ctx, metrics); err != nil {
+		t.Fatal(err)
+	}
+	if got[0].GetDimensionID() != "shareofactiveusers" || metrics[0].GetDimensionID() != "Share of Active Users" {
+		t.Errorf("sent %q, metrics now %q", got[0].GetDimensionID(), metrics[0].GetDimensionID())
+	}
+
+	got = nil
+	metrics[1].DimensionID = String("logns")
+	_, err := c.MetricsService.BulkUpsert(ctx, metrics)
+	var bve *BulkValidationError
+	if !errors.As(err, &bve) || len(bve.Items) != 1 || bve.Items[0].Index != 1 {
+		t.Fatalf("err = %v", err)
+	}
+	if got != nil {
+		t.Error("metrics sent despite typo")
+	}
+}
@@ -56,6 +56,13 @@ Examples:
 * `eu3` becomes `api-eu3`,
 * `us2` becomes `api-us2`
 
+Options
+
+NewClient is a wrapper around New, which accepts functional options to configure the client, for
+example to use a different base URL or to retry failed requests:
+
+	ph, err := planhat.New(apikey, planhat.WithBaseURL("http://localhost:8080"), planhat.WithRetry(3, time.Second, 10*time.Second))
+
 Pagination
 
 Where pagination is provided, Planhat provides the Offset and Limit query parameters as part of the request
@@ -0,0 +1,125 @@
+package planhat
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DryRunRequest is a write recorded, rather than sent, in dry-run mode.
+type DryRunRequest struct {
+	Time      time.Time       `json:"time"`
+	Operation string          `json:"operation,omitempty"`
+	Method    string          `json:"method"`
+	URL       string          `json:"url"`
+	Body      json.RawMessage `json:"body,omitempty"`
+}
+
+// DryRun records the writes a client would have made.  Create one with NewDryRun and pass it to
+// WithDryRun.  It is safe for concurrent use.
+type DryRun struct {
+	mu       sync.Mutex
+	w        io.Writer
+	requests []DryRunRequest
+}
+
+// NewDryRun returns a DryRun that records requests in memory and, if w is not nil, also writes each
+// to w as a line of JSON.
+func NewDryRun(w io.Writer) *DryRun {
+	return &DryRun{w: w}
+}
+
+// Requests returns the requests recorded so far, in the order they were made.
+func (d *DryRun) Requests() []DryRunRequest {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]DryRunRequest(nil), d.requests...)
+}
+
+// Reset clears the recorded requests.
+func (d *DryRun) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.requests = nil
+}
+
+func (d *DryRun) record(r DryRunRequest) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.requests = append(d.requests, r)
+	if d.w == nil {
+		return nil
+	}
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	_, err = d.w.Write(append(line, '\n'))
+	return err
+}
+
+// WithDryRun puts the client in dry-run mode.  Writes (any request other than a GET) are validated
+// and serialized as usual, then recorded in d instead of being sent to planhat, and a synthetic
+// response is returned:
+//
+//   - Create and Update return the record as it was sent.
+//   - Delete reports a single record deleted.
+//   - BulkUpsert reports every record as created, and MetricsService.BulkUpsert as processed.
+//
+// Reads are still sent, so that code which looks records up before changing them behaves as it
+// would for real.  Middleware is not called for recorded requests.
+func WithDryRun(d *DryRun) Option {
+	return func(c *Client) error {
+		c.dryRun = d
+		return nil
+	}
+}
+
+// recordDryRun records req and fills v with a synthetic response.
+func (c *Client) recordDryRun(ctx context.Context, req *http.Request, v interface{}) error {
+	r := DryRunRequest{
+		Time:      time.Now(),
+		Operation: OperationFromContext(ctx),
+		Method:    req.Method,
+		URL:       c.redactURL(req.URL.String()),
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		var body io.Reader = req.Body
+		if req.Header.Get("Content-Encoding") == "gzip" {
+			body = &gunzipBody{body: req.Body}
+		}
+		b, err := io.ReadAll(body)
+		req.Body.Close()
+		if err != nil {
+			return err
+		}
+		r.Body = b
+	}
+	if c.logger != nil {
+		c.logger.DebugContext(ctx, "planhat: dry run", "method", r.Method, "url", r.URL, "request_size", len(r.Body))
+	}
+	if err := c.dryRun.record(r); err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case *DeleteResponse:
+		*v = DeleteResponse{N: 1, OK: 1, DeletedCount: 1}
+	case *UpsertResponse:
+		var items []json.RawMessage
+		json.Unmarshal(r.Body, &items)
+		*v = UpsertResponse{Created: len(items)}
+	case *UpsertMetricsResponse:
+		var items []json.RawMessage
+		json.Unmarshal(r.Body, &items)
+		*v = UpsertMetricsResponse{Processed: len(items)}
+	default:
+		if v != nil && len(r.Body) > 0 {
+			return json.Unmarshal(r.Body, v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package planhat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	var sent []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent = append(sent, r.Method+" "+r.URL.Path)
+		fmt.Fprint(w, `{"_id":"1","name":"Acme"}`)
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	d := NewDryRun(&buf)
+	c, _ := New("key",
+		WithBaseURL(ts.URL),
+		WithMetricsURL(ts.URL+"/dimensiondata"),
+		WithTenantUUID("tenant"),
+		WithCompression(0),
+		WithDryRun(d),
+	)
+	ctx := context.Background()
+
+	co, err := c.CompanyService.Get(ctx, "1")
+	if err != nil || co.GetName() != "Acme" {
+		t.Fatalf("Get = %+v, %v", co, err)
+	}
+	co, err = c.CompanyService.Create(ctx, Company{Name: String("Beta")})
+	if err != nil || co.GetName() != "Beta" {
+		t.Errorf("Create = %+v, %v", co, err)
+	}
+	if dr, err := c.AssetService.DeleteByKey(ctx, ByExternalID("a/1")); err != nil || dr.DeletedCount != 1 {
+		t.Errorf("DeleteByKey = %+v, %v", dr, err)
+	}
+	if ur, err := c.CompanyService.BulkUpsert(ctx, []Company{{Name: String("a")}, {Name: String("b")}}); err != nil || ur.Created != 2 {
+		t.Errorf("BulkUpsert = %+v, %v", ur, err)
+	}
+	if mr, err := c.MetricsService.BulkUpsert(ctx, []Metric{{DimensionID: String("d"), Value: Float64(1), ExternalID: String("a")}}); err != nil || mr.Processed != 1 {
+		t.Errorf("Metrics BulkUpsert = %+v, %v", mr, err)
+	}
+
+	if want := []string{"GET /companies/1"}; fmt.Sprint(sent) != fmt.Sprint(want) {
+		t.Errorf("sent %q, want %q", sent, want)
+	}
+
+	reqs := d.Requests()
+	want := []string{
+		`CompanyService.Create POST /companies {"name":"Beta"}`,
+		`AssetService.DeleteByKey DELETE /assets/extid-a%2F1 `,
+		`CompanyService.BulkUpsert PUT /companies [{"name":"a"},{"name":"b"}]`,
+		`MetricsService.BulkUpsert POST /dimensiondata/[REDACTED] [{"dimensionId":"d","value":1,"externalId":"a"}]`,
+	}
+	if len(reqs) != len(want) {
+		t.Fatalf("recorded %d requests, want %d", len(reqs), len(want))
+	}
+	for i, r := range reqs {
+		got := fmt.Sprintf("%s %s %s %s", r.Operation, r.Method, strings.TrimPrefix(r.URL, ts.URL), string(r.Body))
+		if got != want[i] {
+			t.Errorf("request %d = %s, want %s", i, got, want[i])
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("wrote %d lines, want %d", len(lines), len(want))
+	}
+	var r DryRunRequest
+	if err := json.Unmarshal([]byte(lines[0]), &r); err != nil || r.Method != "POST" {
+		t.Errorf("line 0 = %+v, %v", r, err)
+	}
+
+	d.Reset()
+	if len(d.Requests()) != 0 {
+		t.Error("Reset did not clear requests")
+	}
+}
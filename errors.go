@@ -17,4 +17,9 @@ const (
 	ErrInternalError     = Err("planhat: internal error")
 	ErrUnknown           = Err("planhat: unexpected error occurred")
 	ErrMissingTenantUUID = Err("planhat: missing required tenant uuid for this request")
+	ErrCircuitOpen       = Err("planhat: circuit breaker open")
+	ErrInvalidKey        = Err("planhat: invalid key")
+	ErrAmbiguous         = Err("planhat: identifier matches more than one record")
+	ErrValidation        = Err("planhat: validation failed")
+	ErrSpoolFull         = Err("planhat: metric spool full")
 )
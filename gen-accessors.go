@@ -50,6 +50,7 @@ var (
 	// skipStructs lists structs to skip.
 	skipStructs = map[string]bool{
 		"Client": true,
+		"Result": true,
 	}
 )
 
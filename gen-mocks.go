@@ -0,0 +1,363 @@
+// Copyright 2021 The go-planhat AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+//go:build ignore
+
+// gen-mocks generates mock implementations of the service interfaces in the planhatmock package.
+//
+// It is meant to be used in conjunction with the go generate tool whenever a service interface
+// changes.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+const (
+	outputDir  = "planhatmock"
+	outputFile = "planhatmock.go"
+	pkgPath    = "github.com/darrenparkinson/planhat"
+)
+
+var (
+	verbose = flag.Bool("v", false, "Print verbose log messages")
+
+	sourceTmpl = template.Must(template.New("source").Parse(source))
+
+	// knownImports maps package names used in interface signatures to their import paths.
+	knownImports = map[string]string{
+		"context": "context",
+		"http":    "net/http",
+		"io":      "io",
+		"time":    "time",
+	}
+)
+
+func logf(fmt string, args ...interface{}) {
+	if *verbose {
+		log.Printf(fmt, args...)
+	}
+}
+
+func main() {
+	flag.Parse()
+	fset := token.NewFileSet()
+
+	pkgs, err := parser.ParseDir(fset, ".", sourceFilter, 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pkg, ok := pkgs["planhat"]
+	if !ok {
+		log.Fatal("planhat package not found")
+	}
+
+	t := &templateData{
+		Year:    2021,
+		Package: pkgPath,
+		Imports: map[string]string{"sync": "sync"},
+	}
+	for filename, f := range pkg.Files {
+		logf("Processing %v...", filename)
+		t.processAST(f)
+	}
+	sort.Slice(t.Mocks, func(i, j int) bool { return t.Mocks[i].Name < t.Mocks[j].Name })
+
+	var buf bytes.Buffer
+	if err := sourceTmpl.Execute(&buf, t); err != nil {
+		log.Fatal(err)
+	}
+	clean, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatalf("format.Source:\n%v\n%v", buf.String(), err)
+	}
+	filename := filepath.Join(outputDir, outputFile)
+	logf("Writing %v...", filename)
+	if err := os.WriteFile(filename, clean, 0644); err != nil {
+		log.Fatal(err)
+	}
+	logf("Done.")
+}
+
+func sourceFilter(fi os.FileInfo) bool {
+	return !strings.HasSuffix(fi.Name(), "_test.go")
+}
+
+// processAST adds a mock for each exported interface whose name ends in API.
+func (t *templateData) processAST(f *ast.File) {
+	for _, decl := range f.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts, ok := spec.(*ast.TypeSpec)
+			if !ok || !ts.Name.IsExported() || !strings.HasSuffix(ts.Name.Name, "API") {
+				continue
+			}
+			it, ok := ts.Type.(*ast.InterfaceType)
+			if !ok {
+				continue
+			}
+			m := &mock{Name: ts.Name.Name}
+			for _, field := range it.Methods.List {
+				ft, ok := field.Type.(*ast.FuncType)
+				if !ok || len(field.Names) == 0 {
+					logf("Skipping embedded interface in %v", ts.Name)
+					continue
+				}
+				m.Methods = append(m.Methods, t.newMethod(field.Names[0].Name, ft))
+			}
+			t.Mocks = append(t.Mocks, m)
+		}
+	}
+}
+
+func (t *templateData) newMethod(name string, ft *ast.FuncType) *method {
+	m := &method{Name: name}
+	var params, args, callArgs []string
+	i := 0
+	for _, p := range ft.Params.List {
+		typ := t.typeString(p.Type)
+		names := p.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{ast.NewIdent(fmt.Sprintf("p%d", i))}
+		}
+		for _, n := range names {
+			i++
+			params = append(params, n.Name+" "+typ)
+			args = append(args, n.Name)
+			if _, ok := p.Type.(*ast.Ellipsis); ok {
+				callArgs = append(callArgs, n.Name+"...")
+			} else {
+				callArgs = append(callArgs, n.Name)
+			}
+		}
+	}
+	var results, zeros []string
+	if ft.Results != nil {
+		for _, r := range ft.Results.List {
+			n := len(r.Names)
+			if n == 0 {
+				n = 1
+			}
+			for j := 0; j < n; j++ {
+				typ := t.typeString(r.Type)
+				results = append(results, typ)
+				zeros = append(zeros, fmt.Sprintf("r%d", len(zeros)))
+				m.ZeroDecls = append(m.ZeroDecls, fmt.Sprintf("var r%d %s", len(zeros)-1, typ))
+			}
+		}
+	}
+	m.Params = strings.Join(params, ", ")
+	m.Args = strings.Join(args, ", ")
+	m.CallArgs = strings.Join(callArgs, ", ")
+	m.Zeros = strings.Join(zeros, ", ")
+	switch len(results) {
+	case 0:
+	case 1:
+		m.Results = results[0]
+	default:
+		m.Results = "(" + strings.Join(results, ", ") + ")"
+	}
+	return m
+}
+
+// typeString returns the source for a type as seen from the planhatmock package, qualifying
+// types declared in the planhat package.
+func (t *templateData) typeString(expr ast.Expr) string {
+	switch x := expr.(type) {
+	case *ast.Ident:
+		if x.IsExported() {
+			return "planhat." + x.Name
+		}
+		return x.Name
+	case *ast.StarExpr:
+		return "*" + t.typeString(x.X)
+	case *ast.ArrayType:
+		return "[]" + t.typeString(x.Elt)
+	case *ast.Ellipsis:
+		return "..." + t.typeString(x.Elt)
+	case *ast.MapType:
+		return "map[" + t.typeString(x.Key) + "]" + t.typeString(x.Value)
+	case *ast.ChanType:
+		return "chan " + t.typeString(x.Value)
+	case *ast.SelectorExpr:
+		pkg := x.X.(*ast.Ident).Name
+		path, ok := knownImports[pkg]
+		if !ok {
+			log.Fatalf("unknown package %q; add it to knownImports", pkg)
+		}
+		t.Imports[pkg] = path
+		return pkg + "." + x.Sel.Name
+	case *ast.IndexExpr:
+		return t.typeString(x.X) + "[" + t.typeString(x.Index) + "]"
+	case *ast.IndexListExpr:
+		var idx []string
+		for _, i := range x.Indices {
+			idx = append(idx, t.typeString(i))
+		}
+		return t.typeString(x.X) + "[" + strings.Join(idx, ", ") + "]"
+	case *ast.InterfaceType:
+		return "interface{}"
+	case *ast.FuncType:
+		var params, results []string
+		for _, p := range x.Params.List {
+			for range max(1, len(p.Names)) {
+				params = append(params, t.typeString(p.Type))
+			}
+		}
+		if x.Results != nil {
+			for _, r := range x.Results.List {
+				for range max(1, len(r.Names)) {
+					results = append(results, t.typeString(r.Type))
+				}
+			}
+		}
+		s := "func(" + strings.Join(params, ", ") + ")"
+		switch len(results) {
+		case 0:
+		case 1:
+			s += " " + results[0]
+		default:
+			s += " (" + strings.Join(results, ", ") + ")"
+		}
+		return s
+	}
+	log.Fatalf("typeString: unsupported type %T", expr)
+	return ""
+}
+
+type templateData struct {
+	Year    int
+	Package string
+	Imports map[string]string
+	Mocks   []*mock
+}
+
+type mock struct {
+	Name    string
+	Methods []*method
+}
+
+type method struct {
+	Name      string
+	Params    string // parameter list with names and types
+	Args      string // parameter names
+	CallArgs  string // parameter names, with variadic expansion
+	Results   string
+	ZeroDecls []string
+	Zeros     string
+}
+
+const source = `// Copyright {{.Year}} The go-planhat AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+// Code generated by gen-mocks; DO NOT EDIT.
+
+// Package planhatmock provides mock implementations of the planhat service interfaces for use in
+// tests.  Each mock records the calls made to it and delegates to an optional function field for
+// each method, returning zero values if the function is not set:
+//
+//	companies := &planhatmock.CompanyAPI{
+//		GetFunc: func(ctx context.Context, id string) (*planhat.Company, error) {
+//			return &planhat.Company{ID: planhat.String(id)}, nil
+//		},
+//	}
+//	company, _ := companies.Get(ctx, "123")
+//	log.Println(len(companies.CallsTo("Get")))
+package planhatmock
+
+import (
+  {{range .Imports -}}
+  "{{.}}"
+  {{end}}
+  "{{.Package}}"
+)
+
+// Call records a single call to a mock method.
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// recorder records calls to a mock.
+type recorder struct {
+	mu    sync.Mutex
+	calls []Call
+}
+
+func (r *recorder) record(method string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, Call{Method: method, Args: args})
+}
+
+// Calls returns all calls made to the mock in order.
+func (r *recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// CallsTo returns the calls made to the named method in order.
+func (r *recorder) CallsTo(method string) []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var calls []Call
+	for _, c := range r.calls {
+		if c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// Reset clears the recorded calls.
+func (r *recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
+{{range .Mocks}}{{$mock := .Name}}
+// {{.Name}} is a mock implementation of planhat.{{.Name}}.
+type {{.Name}} struct {
+	recorder
+{{range .Methods}}
+	// {{.Name}}Func is called by {{.Name}} if set.
+	{{.Name}}Func func({{.Params}}) {{.Results}}
+{{- end}}
+}
+
+var _ planhat.{{.Name}} = (*{{.Name}})(nil)
+{{range .Methods}}
+// {{.Name}} records the call and calls {{.Name}}Func if set.
+func (m *{{$mock}}) {{.Name}}({{.Params}}) {{.Results}} {
+	m.record("{{.Name}}"{{if .Args}}, {{.Args}}{{end}})
+	if m.{{.Name}}Func != nil {
+		{{if .Results}}return {{end}}m.{{.Name}}Func({{.CallArgs}})
+		{{- if not .Results}}
+		return{{end}}
+	}
+	{{- range .ZeroDecls}}
+	{{.}}
+	{{- end}}
+	{{- if .Results}}
+	return {{.Zeros}}{{end}}
+}
+{{end}}{{end}}`
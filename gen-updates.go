@@ -0,0 +1,216 @@
+// Copyright 2021 The go-planhat AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+//go:build ignore
+
+// gen-updates generates typed Set and Clear methods on the partial update builders, such as
+// CompanyUpdate, for each pointer field of the model they update.
+//
+// It is meant to be used in conjunction with the go generate tool whenever a model changes.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"log"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+const outputFile = "planhat-updates.go"
+
+var (
+	verbose = flag.Bool("v", false, "Print verbose log messages")
+
+	sourceTmpl = template.Must(template.New("source").Parse(source))
+
+	// builders maps models to the update builders generated for them.
+	builders = map[string]string{
+		"Asset":   "AssetUpdate",
+		"Company": "CompanyUpdate",
+	}
+
+	// skipFields lists "model.field" combos to skip.
+	skipFields = map[string]bool{
+		"Asset.ID":   true,
+		"Company.ID": true,
+	}
+
+	// knownImports maps package names used in model fields to their import paths.
+	knownImports = map[string]string{
+		"time": "time",
+	}
+)
+
+func logf(fmt string, args ...interface{}) {
+	if *verbose {
+		log.Printf(fmt, args...)
+	}
+}
+
+func main() {
+	flag.Parse()
+	fset := token.NewFileSet()
+
+	pkgs, err := parser.ParseDir(fset, ".", sourceFilter, 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pkg, ok := pkgs["planhat"]
+	if !ok {
+		log.Fatal("planhat package not found")
+	}
+
+	t := &templateData{Year: 2021, Imports: map[string]string{}}
+	for filename, f := range pkg.Files {
+		logf("Processing %v...", filename)
+		t.processAST(fset, f)
+	}
+	sort.Slice(t.Setters, func(i, j int) bool {
+		if t.Setters[i].Builder != t.Setters[j].Builder {
+			return t.Setters[i].Builder < t.Setters[j].Builder
+		}
+		return t.Setters[i].Field < t.Setters[j].Field
+	})
+
+	var buf bytes.Buffer
+	if err := sourceTmpl.Execute(&buf, t); err != nil {
+		log.Fatal(err)
+	}
+	clean, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatalf("format.Source:\n%v\n%v", buf.String(), err)
+	}
+	logf("Writing %v...", outputFile)
+	if err := os.WriteFile(outputFile, clean, 0644); err != nil {
+		log.Fatal(err)
+	}
+	logf("Done.")
+}
+
+func sourceFilter(fi os.FileInfo) bool {
+	return !strings.HasSuffix(fi.Name(), "_test.go") && fi.Name() != outputFile
+}
+
+// processAST adds a setter for each exported pointer field with a JSON name in the models that have
+// update builders.
+func (t *templateData) processAST(fset *token.FileSet, f *ast.File) {
+	for _, decl := range f.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+			builder, ok := builders[ts.Name.Name]
+			if !ok {
+				continue
+			}
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+			for _, field := range st.Fields.List {
+				if len(field.Names) == 0 || !field.Names[0].IsExported() || field.Tag == nil {
+					continue
+				}
+				name := field.Names[0].Name
+				if skipFields[ts.Name.Name+"."+name] {
+					logf("Field %v.%v is in skip list; skipping.", ts.Name, name)
+					continue
+				}
+				se, ok := field.Type.(*ast.StarExpr)
+				if !ok {
+					logf("Field %v.%v is not a pointer; skipping.", ts.Name, name)
+					continue
+				}
+				tag, err := strconv.Unquote(field.Tag.Value)
+				if err != nil {
+					log.Fatal(err)
+				}
+				jsonName, _, _ := strings.Cut(reflect.StructTag(tag).Get("json"), ",")
+				if jsonName == "" || jsonName == "-" {
+					continue
+				}
+				t.Setters = append(t.Setters, &setter{
+					Builder:  builder,
+					Field:    name,
+					JSONName: jsonName,
+					Type:     t.typeString(fset, se.X),
+				})
+			}
+		}
+	}
+}
+
+// typeString returns the source for a type, recording any imports it needs.
+func (t *templateData) typeString(fset *token.FileSet, expr ast.Expr) string {
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if se, ok := n.(*ast.SelectorExpr); ok {
+			pkg := se.X.(*ast.Ident).Name
+			path, ok := knownImports[pkg]
+			if !ok {
+				log.Fatalf("unknown package %q; add it to knownImports", pkg)
+			}
+			t.Imports[pkg] = path
+		}
+		return true
+	})
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, expr); err != nil {
+		log.Fatal(err)
+	}
+	return buf.String()
+}
+
+type templateData struct {
+	Year    int
+	Imports map[string]string
+	Setters []*setter
+}
+
+type setter struct {
+	Builder  string
+	Field    string
+	JSONName string
+	Type     string
+}
+
+const source = `// Copyright {{.Year}} The go-planhat AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+// Code generated by gen-updates; DO NOT EDIT.
+package planhat
+{{if .Imports}}
+import (
+  {{range .Imports -}}
+  "{{.}}"
+  {{end -}}
+)
+{{end}}
+{{range .Setters}}
+// Set{{.Field}} sets the {{.JSONName}} field.
+func (u *{{.Builder}}) Set{{.Field}}(v {{.Type}}) *{{.Builder}} {
+	return u.Set("{{.JSONName}}", v)
+}
+
+// Clear{{.Field}} sets the {{.JSONName}} field to null.
+func (u *{{.Builder}}) Clear{{.Field}}() *{{.Builder}} {
+	return u.Clear("{{.JSONName}}")
+}
+{{end}}`
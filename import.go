@@ -0,0 +1,424 @@
+package planhat
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ImportFormat is the format of a file of metrics to import.
+type ImportFormat int
+
+// Formats supported by ImportMetrics.
+const (
+	// ImportCSV is comma separated values with a header row naming the columns.
+	ImportCSV ImportFormat = iota
+	// ImportJSONL is one JSON object per line.
+	ImportJSONL
+)
+
+// MetricColumns names the columns, or JSON fields, holding each field of a Metric.  Empty names
+// default to the Metric's JSON field names, e.g. "dimensionId".
+type MetricColumns struct {
+	DimensionID string
+	Value       string
+	ExternalID  string
+	Model       string
+	Date        string
+}
+
+func (c MetricColumns) withDefaults() MetricColumns {
+	set := func(s *string, def string) {
+		if *s == "" {
+			*s = def
+		}
+	}
+	set(&c.DimensionID, "dimensionId")
+	set(&c.Value, "value")
+	set(&c.ExternalID, "externalId")
+	set(&c.Model, "model")
+	set(&c.Date, "date")
+	return c
+}
+
+// MetricImportOptions configures ImportMetrics.
+type MetricImportOptions struct {
+	// Format of the input.  Defaults to ImportCSV.
+	Format ImportFormat
+
+	// Columns maps the input to metrics.  The dimension, value and externalId columns are required in
+	// a CSV header; rows missing a model or date are sent without one.
+	Columns MetricColumns
+
+	// NormaliseDimensions converts dimension IDs such as "Share of Active Users" to valid IDs using
+	// NormaliseDimensionID before they are validated.
+	NormaliseDimensions bool
+
+	// BatchSize is the maximum number of metrics sent per request.  Defaults to 5,000.
+	BatchSize int
+
+	// Rejects, if not nil, receives the rows that could not be imported with the reason.  For CSV it
+	// is the header and rejected rows with an extra "reason" column, and for JSONL a JSON object per
+	// rejected row holding its line number, the reason and the original record.
+	Rejects io.Writer
+}
+
+// MetricImportResult summarises an import.
+type MetricImportResult struct {
+	// Rows is the number of rows read, excluding any header and blank lines.
+	Rows int
+	// Imported is the number of metrics sent to planhat.
+	Imported int
+	// Rejected is the number of rows that could not be imported.
+	Rejected int
+	// Batches is the number of requests made.
+	Batches int
+	// Errors are any errors reported by planhat in its responses.
+	Errors []interface{}
+}
+
+// importRow is a row read from the input.
+type importRow struct {
+	line   int
+	fields []string        // the CSV record
+	raw    json.RawMessage // the JSONL record
+	metric Metric
+}
+
+// ImportMetrics reads metrics from r, validates them and sends them to planhat in batches using
+// api.BulkUpsert, usually the MetricsService.  Rows that can't be parsed or fail validation are
+// written to opts.Rejects and the import continues.  An error is returned if the input can't be read
+// or a batch can't be sent, in which case the result reports the rows imported before it and the rows
+// rejected so far are still written to opts.Rejects.
+func ImportMetrics(ctx context.Context, api MetricsAPI, r io.Reader, opts MetricImportOptions) (res *MetricImportResult, err error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = maxMetricsBatch
+	}
+	cols := opts.Columns.withDefaults()
+	var src rowSource
+	switch opts.Format {
+	case ImportCSV:
+		s, err := newCSVSource(r, cols, opts.Rejects)
+		if err != nil {
+			return nil, err
+		}
+		src = s
+	case ImportJSONL:
+		src = newJSONLSource(r, cols, opts.Rejects)
+	default:
+		return nil, fmt.Errorf("planhat: unknown import format %d", opts.Format)
+	}
+
+	defer func() {
+		if ferr := src.flush(); err == nil {
+			err = ferr
+		}
+	}()
+	res = &MetricImportResult{}
+	imp := &metricImport{api: api, src: src, res: res}
+	var batch []importRow
+	for {
+		row, reason, err := src.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return res, err
+		}
+		res.Rows++
+		if reason == "" {
+			if opts.NormaliseDimensions && row.metric.DimensionID != nil {
+				row.metric.DimensionID = String(NormaliseDimensionID(*row.metric.DimensionID))
+			}
+			reason = importReason(row.metric.Validate())
+		}
+		if reason != "" {
+			if err := imp.reject(row, reason); err != nil {
+				return res, err
+			}
+			continue
+		}
+		batch = append(batch, row)
+		if len(batch) == opts.BatchSize {
+			if err := imp.send(ctx, batch); err != nil {
+				return res, err
+			}
+			batch = batch[:0]
+		}
+	}
+	return res, imp.send(ctx, batch)
+}
+
+type metricImport struct {
+	api MetricsAPI
+	src rowSource
+	res *MetricImportResult
+}
+
+func (imp *metricImport) reject(row importRow, reason string) error {
+	imp.res.Rejected++
+	return imp.src.reject(row, reason)
+}
+
+// send upserts a batch.  Rows rejected by the client's own validation, e.g. by a DimensionRegistry,
+// are rejected and the rest of the batch sent again.
+func (imp *metricImport) send(ctx context.Context, batch []importRow) error {
+	for len(batch) > 0 {
+		metrics := make([]Metric, len(batch))
+		for i, row := range batch {
+			metrics[i] = row.metric
+		}
+		resp, err := imp.api.BulkUpsert(ctx, metrics)
+		var bve *BulkValidationError
+		if errors.As(err, &bve) && len(bve.Items) > 0 {
+			invalid := map[int]error{}
+			for _, item := range bve.Items {
+				invalid[item.Index] = item.Err
+			}
+			var valid []importRow
+			for i, row := range batch {
+				if err, ok := invalid[i]; ok {
+					if err := imp.reject(row, importReason(err)); err != nil {
+						return err
+					}
+				} else {
+					valid = append(valid, row)
+				}
+			}
+			batch = valid
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		imp.res.Batches++
+		imp.res.Imported += len(batch)
+		if resp != nil {
+			imp.res.Errors = append(imp.res.Errors, resp.Errors...)
+		}
+		return nil
+	}
+	return nil
+}
+
+// importReason returns the reason for rejecting a row that failed validation, without the prefix
+// repeated on every row.
+func importReason(err error) string {
+	var ve *ValidationError
+	if errors.As(err, &ve) {
+		msgs := make([]string, len(ve.Fields))
+		for i, f := range ve.Fields {
+			msgs[i] = f.Error()
+		}
+		return strings.Join(msgs, "; ")
+	}
+	if err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// rowSource reads rows in one of the import formats and writes the rejected ones back in it.
+type rowSource interface {
+	// next returns the next row, with the reason it can't be imported if it couldn't be parsed, or
+	// io.EOF at the end of the input.
+	next() (importRow, string, error)
+	reject(row importRow, reason string) error
+	flush() error
+}
+
+// metricFromFields builds a metric from the values returned by get, which reports false for a
+// missing or empty value.
+func metricFromFields(cols MetricColumns, get func(col string) (string, bool)) (Metric, string) {
+	var m Metric
+	str := func(col string) *string {
+		if v, ok := get(col); ok {
+			return String(v)
+		}
+		return nil
+	}
+	m.DimensionID = str(cols.DimensionID)
+	m.ExternalID = str(cols.ExternalID)
+	m.Model = str(cols.Model)
+	m.Date = str(cols.Date)
+	if v, ok := get(cols.Value); ok {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return m, fmt.Sprintf("value: %q is not a number", v)
+		}
+		m.Value = Float64(f)
+	}
+	return m, ""
+}
+
+type csvSource struct {
+	r       *csv.Reader
+	cols    MetricColumns
+	header  []string
+	index   map[string]int
+	rejects *csv.Writer
+	started bool
+}
+
+func newCSVSource(r io.Reader, cols MetricColumns, rejects io.Writer) (*csvSource, error) {
+	s := &csvSource{r: csv.NewReader(r), cols: cols, index: map[string]int{}}
+	s.r.FieldsPerRecord = -1
+	s.r.TrimLeadingSpace = true
+	header, err := s.r.Read()
+	if err == io.EOF {
+		return nil, errors.New("planhat: import has no header row")
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Spreadsheets often save a byte order mark before the first column name.
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	for i, name := range header {
+		s.index[strings.TrimSpace(name)] = i
+	}
+	for _, col := range []string{cols.DimensionID, cols.Value, cols.ExternalID} {
+		if _, ok := s.index[col]; !ok {
+			return nil, fmt.Errorf("planhat: import column %q not found", col)
+		}
+	}
+	s.header = header
+	if rejects != nil {
+		s.rejects = csv.NewWriter(rejects)
+	}
+	return s, nil
+}
+
+func (s *csvSource) next() (importRow, string, error) {
+	for {
+		fields, err := s.r.Read()
+		var pe *csv.ParseError
+		if errors.As(err, &pe) {
+			return importRow{line: pe.StartLine, fields: fields}, pe.Err.Error(), nil
+		}
+		if err != nil {
+			return importRow{}, "", err
+		}
+		line, _ := s.r.FieldPos(0)
+		if len(fields) == 1 && strings.TrimSpace(fields[0]) == "" {
+			continue
+		}
+		row := importRow{line: line, fields: fields}
+		get := func(col string) (string, bool) {
+			i, ok := s.index[col]
+			if !ok || i >= len(fields) {
+				return "", false
+			}
+			v := strings.TrimSpace(fields[i])
+			return v, v != ""
+		}
+		var reason string
+		row.metric, reason = metricFromFields(s.cols, get)
+		return row, reason, nil
+	}
+}
+
+func (s *csvSource) reject(row importRow, reason string) error {
+	if s.rejects == nil {
+		return nil
+	}
+	if !s.started {
+		s.started = true
+		if err := s.rejects.Write(append(append([]string{}, s.header...), "reason")); err != nil {
+			return err
+		}
+	}
+	// Pad short rows so that the reason is always in the last column.
+	rec := append([]string{}, row.fields...)
+	for len(rec) < len(s.header) {
+		rec = append(rec, "")
+	}
+	return s.rejects.Write(append(rec, reason))
+}
+
+func (s *csvSource) flush() error {
+	if s.rejects == nil {
+		return nil
+	}
+	s.rejects.Flush()
+	return s.rejects.Error()
+}
+
+type jsonlSource struct {
+	r       *bufio.Reader
+	cols    MetricColumns
+	line    int
+	rejects io.Writer
+}
+
+func newJSONLSource(r io.Reader, cols MetricColumns, rejects io.Writer) *jsonlSource {
+	return &jsonlSource{r: bufio.NewReader(r), cols: cols, rejects: rejects}
+}
+
+func (s *jsonlSource) next() (importRow, string, error) {
+	for {
+		b, err := s.r.ReadBytes('\n')
+		if err == io.EOF && len(b) > 0 {
+			err = nil
+		}
+		if err != nil {
+			return importRow{}, "", err
+		}
+		s.line++
+		b = bytes.TrimSpace(b)
+		if len(b) == 0 {
+			continue
+		}
+		row := importRow{line: s.line, raw: append(json.RawMessage{}, b...)}
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(b, &obj); err != nil {
+			// Keep the rejects file valid JSON by storing an unparseable line as a string.
+			row.raw, _ = json.Marshal(string(b))
+			return row, "invalid JSON: " + err.Error(), nil
+		}
+		get := func(col string) (string, bool) {
+			v := obj[col]
+			if len(v) == 0 || string(v) == "null" {
+				return "", false
+			}
+			var s string
+			if json.Unmarshal(v, &s) != nil {
+				// Not a string, so use the JSON as is, which allows numbers.
+				s = string(v)
+			}
+			s = strings.TrimSpace(s)
+			return s, s != ""
+		}
+		var reason string
+		row.metric, reason = metricFromFields(s.cols, get)
+		return row, reason, nil
+	}
+}
+
+func (s *jsonlSource) reject(row importRow, reason string) error {
+	if s.rejects == nil {
+		return nil
+	}
+	b, err := json.Marshal(struct {
+		Line   int             `json:"line"`
+		Reason string          `json:"reason"`
+		Record json.RawMessage `json:"record"`
+	}{row.line, reason, row.raw})
+	if err != nil {
+		return err
+	}
+	_, err = s.rejects.Write(append(b, '\n'))
+	return err
+}
+
+func (s *jsonlSource) flush() error {
+	return nil
+}
@@ -0,0 +1,94 @@
+package planhat
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestImportMetricsCSV(t *testing.T) {
+	in := "\ufeffMetric,Account,Count,When\n" +
+		"Logins,acme,3,2021-03-01\n" +
+		"logins,,4,2021-03-01\n" +
+		"\n" +
+		"seats,acme,lots,2021-03-01\n" +
+		"seats,acme,10\n" +
+		"seats,globex,12,yesterday\n"
+	fake := &fakeMetrics{}
+	var rejects strings.Builder
+	res, err := ImportMetrics(context.Background(), fake, strings.NewReader(in), MetricImportOptions{
+		Columns:             MetricColumns{DimensionID: "Metric", ExternalID: "Account", Value: "Count", Date: "When"},
+		NormaliseDimensions: true,
+		BatchSize:           1,
+		Rejects:             &rejects,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Rows != 5 || res.Imported != 2 || res.Rejected != 3 || res.Batches != 2 {
+		t.Errorf("result = %+v", res)
+	}
+	if got := fake.sent[0][0]; got.GetDimensionID() != "logins" || *got.Value != 3 || got.GetDate() != "2021-03-01" {
+		t.Errorf("sent %s", metricString(got))
+	}
+	if got := fake.sent[1][0]; got.Date != nil || got.GetExternalID() != "acme" {
+		t.Errorf("sent %s", metricString(got))
+	}
+	want := "Metric,Account,Count,When,reason\n" +
+		"logins,,4,2021-03-01,externalId: required\n" +
+		"seats,acme,lots,2021-03-01,\"value: \"\"lots\"\" is not a number\"\n" +
+		"seats,globex,12,yesterday,date: must be an ISO 8601 date\n"
+	if rejects.String() != want {
+		t.Errorf("rejects =\n%s\nwant\n%s", rejects.String(), want)
+	}
+
+	if _, err := ImportMetrics(context.Background(), fake, strings.NewReader("dimensionId,value\n"), MetricImportOptions{}); err == nil {
+		t.Error("expected error for missing externalId column")
+	}
+}
+
+func TestImportMetricsJSONL(t *testing.T) {
+	in := `{"dimensionId":"logins","value":3,"externalId":"acme","model":"Company"}
+{"dimensionId":"logins","value":"2.5","externalId":"globex"}
+{"dimensionId":"logins",
+{"dimensionId":"logins","value":1,"externalId":"initech","model":"Deal"}
+{"dimensionId":"logns","value":1,"externalId":"acme"}`
+	c, _ := New("key", WithTenantUUID("t"), WithDryRun(NewDryRun(nil)), WithDimensionRegistry(NewDimensionRegistry(false, "logins")))
+	var rejects strings.Builder
+	res, err := ImportMetrics(context.Background(), c.MetricsService, strings.NewReader(in), MetricImportOptions{Format: ImportJSONL, Rejects: &rejects})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Rows != 5 || res.Imported != 2 || res.Rejected != 3 || res.Batches != 1 {
+		t.Errorf("result = %+v", res)
+	}
+	lines := strings.Split(strings.TrimSpace(rejects.String()), "\n")
+	want := []string{
+		`{"line":3,"reason":"invalid JSON: unexpected end of JSON input","record":"{\"dimensionId\":\"logins\","}`,
+		`{"line":4,"reason":"model: must be one of Company, EndUser, Asset or Project","record":{"dimensionId":"logins","value":1,"externalId":"initech","model":"Deal"}}`,
+		`{"line":5,"reason":"dimensionId: unknown dimension \"logns\", did you mean \"logins\"?","record":{"dimensionId":"logns","value":1,"externalId":"acme"}}`,
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("rejects =\n%s", rejects.String())
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("reject %d = %s\nwant %s", i, lines[i], want[i])
+		}
+	}
+}
+
+func TestImportMetricsSendError(t *testing.T) {
+	fake := &fakeMetrics{err: errors.New("unavailable")}
+	in := "dimensionId,value,externalId\nlogins,1,\nlogins,1,acme\n"
+	var rejects strings.Builder
+	res, err := ImportMetrics(context.Background(), fake, strings.NewReader(in), MetricImportOptions{Rejects: &rejects})
+	if err == nil || res.Rows != 2 || res.Imported != 0 || res.Rejected != 1 {
+		t.Errorf("ImportMetrics = %+v, %v", res, err)
+	}
+	// The rows rejected before the error are still written.
+	if want := "dimensionId,value,externalId,reason\nlogins,1,,externalId: required\n"; rejects.String() != want {
+		t.Errorf("rejects =\n%s\nwant\n%s", rejects.String(), want)
+	}
+}
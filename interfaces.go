@@ -0,0 +1,66 @@
+package planhat
+
+import (
+	"context"
+	"time"
+)
+
+// CompanyAPI is the set of methods provided by CompanyService.  Accept it rather than *CompanyService
+// in your own code to allow a fake, such as the one in the planhatmock package, to be used in tests.
+type CompanyAPI interface {
+	Create(ctx context.Context, company Company) (*Company, error)
+	Update(ctx context.Context, id string, company Company) (*Company, error)
+	UpdateByKey(ctx context.Context, key Key, company Company) (*Company, error)
+	UpdateFields(ctx context.Context, key Key, update *CompanyUpdate) (*Company, error)
+	UpdateIfChanged(ctx context.Context, key Key, company Company) (*Company, []Change, error)
+	Get(ctx context.Context, id string) (*Company, error)
+	GetByKey(ctx context.Context, key Key) (*Company, error)
+	GetByExternalID(ctx context.Context, externalID string) (*Company, error)
+	GetBySourceID(ctx context.Context, sourceID string) (*Company, error)
+	GetMany(ctx context.Context, keys []Key) []Result[Company]
+	List(ctx context.Context, options ...*CompanyListOptions) ([]*Company, error)
+	ListEach(ctx context.Context, fn func(*Company) error, options ...*CompanyListOptions) error
+	LeanList(ctx context.Context, options ...*LeanCompanyListOptions) ([]*LeanCompany, error)
+	LeanListEach(ctx context.Context, fn func(*LeanCompany) error, options ...*LeanCompanyListOptions) error
+	Delete(ctx context.Context, id string) (*DeleteResponse, error)
+	DeleteByKey(ctx context.Context, key Key) (*DeleteResponse, error)
+	BulkUpsert(ctx context.Context, companies []Company) (*UpsertResponse, error)
+}
+
+// AssetAPI is the set of methods provided by AssetService.
+type AssetAPI interface {
+	Create(ctx context.Context, asset Asset) (*Asset, error)
+	Update(ctx context.Context, id string, asset Asset) (*Asset, error)
+	UpdateByKey(ctx context.Context, key Key, asset Asset) (*Asset, error)
+	UpdateFields(ctx context.Context, key Key, update *AssetUpdate) (*Asset, error)
+	UpdateIfChanged(ctx context.Context, key Key, asset Asset) (*Asset, []Change, error)
+	Get(ctx context.Context, id string) (*Asset, error)
+	GetByKey(ctx context.Context, key Key) (*Asset, error)
+	GetByExternalID(ctx context.Context, externalID string) (*Asset, error)
+	GetBySourceID(ctx context.Context, sourceID string) (*Asset, error)
+	GetMany(ctx context.Context, keys []Key) []Result[Asset]
+	List(ctx context.Context, options ...*AssetListOptions) ([]*Asset, error)
+	ListEach(ctx context.Context, fn func(*Asset) error, options ...*AssetListOptions) error
+	Delete(ctx context.Context, id string) (*DeleteResponse, error)
+	DeleteByKey(ctx context.Context, key Key) (*DeleteResponse, error)
+	BulkUpsert(ctx context.Context, assets []Asset) (*UpsertResponse, error)
+}
+
+// MetricsAPI is the set of methods provided by MetricsService.
+type MetricsAPI interface {
+	List(ctx context.Context, options ...*MetricsListOptions) ([]*DimensionData, error)
+	ListRange(ctx context.Context, cid, dimID string, from, to time.Time) ([]*DimensionData, error)
+	BulkUpsert(ctx context.Context, metrics []Metric) (*UpsertMetricsResponse, error)
+}
+
+// UserAPI is the set of methods provided by UserService.
+type UserAPI interface {
+	List(ctx context.Context) ([]*User, error)
+}
+
+var (
+	_ CompanyAPI = (*CompanyService)(nil)
+	_ AssetAPI   = (*AssetService)(nil)
+	_ MetricsAPI = (*MetricsService)(nil)
+	_ UserAPI    = (*UserService)(nil)
+)
@@ -0,0 +1,61 @@
+package planhat
+
+import "net/url"
+
+// keyKind is the type of identifier held by a Key.
+type keyKind int
+
+const (
+	keyID keyKind = iota
+	keyExternalID
+	keySourceID
+)
+
+// Key identifies a planhat record by its planhat _id, its externalId or its sourceId.  Use ByID,
+// ByExternalID or BySourceID to create one.  Keys take care of the extid-/srcid- prefixes planhat
+// uses for keyables and of escaping the value for use in a URL, so external IDs containing slashes
+// or spaces work as expected.
+type Key struct {
+	kind  keyKind
+	value string
+}
+
+// ByID returns a Key for the record with the given planhat _id.
+func ByID(id string) Key { return Key{kind: keyID, value: id} }
+
+// ByExternalID returns a Key for the record with the given externalId.
+func ByExternalID(externalID string) Key { return Key{kind: keyExternalID, value: externalID} }
+
+// BySourceID returns a Key for the record with the given sourceId.
+func BySourceID(sourceID string) Key { return Key{kind: keySourceID, value: sourceID} }
+
+// Value returns the identifier held by the key, without any prefix.
+func (k Key) Value() string { return k.value }
+
+// IsID reports whether the key holds a planhat _id.
+func (k Key) IsID() bool { return k.kind == keyID }
+
+// IsExternalID reports whether the key holds an externalId.
+func (k Key) IsExternalID() bool { return k.kind == keyExternalID }
+
+// IsSourceID reports whether the key holds a sourceId.
+func (k Key) IsSourceID() bool { return k.kind == keySourceID }
+
+// String returns the key as planhat expects it, e.g. "extid-123", without escaping.
+func (k Key) String() string {
+	switch k.kind {
+	case keyExternalID:
+		return "extid-" + k.value
+	case keySourceID:
+		return "srcid-" + k.value
+	}
+	return k.value
+}
+
+// path returns the key escaped for use as a URL path segment, or ErrInvalidKey if it is empty.
+func (k Key) path() (string, error) {
+	if k.value == "" {
+		return "", ErrInvalidKey
+	}
+	return url.PathEscape(k.String()), nil
+}
@@ -0,0 +1,63 @@
+package planhat
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestKey_String(t *testing.T) {
+	tests := []struct {
+		key  Key
+		want string
+		path string
+	}{
+		{ByID("5f0c"), "5f0c", "5f0c"},
+		{ByExternalID("acme/eu 1"), "extid-acme/eu 1", "extid-acme%2Feu%201"},
+		{BySourceID("sf?1"), "srcid-sf?1", "srcid-sf%3F1"},
+	}
+	for _, tt := range tests {
+		if got := tt.key.String(); got != tt.want {
+			t.Errorf("got: %v; want %v", got, tt.want)
+		}
+		if got, _ := tt.key.path(); got != tt.path {
+			t.Errorf("got path: %v; want %v", got, tt.path)
+		}
+	}
+	if _, err := ByExternalID("").path(); err != ErrInvalidKey {
+		t.Errorf("got error: %v; want %v", err, ErrInvalidKey)
+	}
+}
+
+func TestKey_Requests(t *testing.T) {
+	var got []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Method+" "+r.URL.EscapedPath())
+		fmt.Fprint(w, `{}`)
+	}))
+	defer ts.Close()
+
+	c, _ := New("key", WithBaseURL(ts.URL))
+	ctx := context.Background()
+	c.CompanyService.GetByKey(ctx, ByExternalID("a/b"))
+	c.CompanyService.GetByExternalID(ctx, "a b")
+	c.AssetService.GetBySourceID(ctx, "x/y")
+	c.AssetService.UpdateByKey(ctx, BySourceID("x y"), Asset{})
+	c.CompanyService.DeleteByKey(ctx, ByExternalID("a/b"))
+	if _, err := c.CompanyService.DeleteByKey(ctx, ByID("")); err != ErrInvalidKey {
+		t.Errorf("got error: %v; want %v", err, ErrInvalidKey)
+	}
+
+	want := []string{
+		"GET /companies/extid-a%2Fb",
+		"GET /companies/extid-a%20b",
+		"GET /assets/srcid-x%2Fy",
+		"PUT /assets/srcid-x%20y",
+		"DELETE /companies/extid-a%2Fb",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got requests: %q; want %q", got, want)
+	}
+}
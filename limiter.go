@@ -0,0 +1,159 @@
+package planhat
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimiter controls the rate at which requests are sent to planhat.  Wait blocks until a request
+// may be sent, returning an error if the context is cancelled first.  Implementations must be safe
+// for concurrent use, allowing a single limiter to be shared across several clients that use the
+// same planhat tenant.  A *rate.Limiter from golang.org/x/time/rate satisfies this interface.
+type RateLimiter interface {
+	Wait(ctx context.Context) error
+}
+
+// ResponseObserver may be implemented by a RateLimiter that adapts to the responses from planhat.
+// ObserveResponse is called with the response to every attempt.
+type ResponseObserver interface {
+	ObserveResponse(res *http.Response)
+}
+
+// WithRateLimiter sets the rate limiter used for requests to the main planhat API.  Pass the same
+// limiter to several clients to share a budget between them.
+func WithRateLimiter(l RateLimiter) Option {
+	return func(c *Client) error {
+		if l == nil {
+			return errors.New("rate limiter required")
+		}
+		c.lim = l
+		return nil
+	}
+}
+
+// WithMetricsRateLimiter sets the rate limiter used for pushing metrics to the analytics endpoint,
+// which has a separate budget to the main API.
+func WithMetricsRateLimiter(l RateLimiter) Option {
+	return func(c *Client) error {
+		if l == nil {
+			return errors.New("rate limiter required")
+		}
+		c.metricsLim = l
+		return nil
+	}
+}
+
+// endpointFor returns the endpoint the request is for.
+func (c *Client) endpointFor(req *http.Request) Endpoint {
+	if c.MetricsURL != "" && strings.HasPrefix(req.URL.String(), c.MetricsURL) {
+		return EndpointMetrics
+	}
+	return EndpointAPI
+}
+
+// limiterFor returns the rate limiter for the given endpoint.
+func (c *Client) limiterFor(e Endpoint) RateLimiter {
+	if e == EndpointMetrics {
+		return c.metricsLim
+	}
+	return c.lim
+}
+
+// wait blocks on the rate limiter, returning the context error if waiting is aborted.
+func wait(ctx context.Context, l RateLimiter) error {
+	if err := l.Wait(ctx); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if strings.Contains(err.Error(), "would exceed context deadline") {
+			// x/time/rate returns early when the wait would exceed the context deadline.
+			return context.DeadlineExceeded
+		}
+		return err
+	}
+	return nil
+}
+
+// AdaptiveLimiter is a token bucket rate limiter that slows down when planhat responds with 429 Too
+// Many Requests, honouring any Retry-After delay, and gradually speeds back up to its configured
+// rate as requests succeed.  It is safe for concurrent use and may be shared between clients.
+type AdaptiveLimiter struct {
+	mu          sync.Mutex
+	lim         *rate.Limiter
+	max         rate.Limit
+	min         rate.Limit
+	pausedUntil time.Time
+	successes   int
+}
+
+// adaptiveRecovery is the number of consecutive successful responses after which an adaptive
+// limiter increases its rate again.
+const adaptiveRecovery = 20
+
+// NewAdaptiveLimiter returns an AdaptiveLimiter allowing up to rps requests per second with the
+// given burst.  After a 429 response the rate is halved, down to a minimum of one twentieth of rps.
+func NewAdaptiveLimiter(rps float64, burst int) *AdaptiveLimiter {
+	return &AdaptiveLimiter{
+		lim: rate.NewLimiter(rate.Limit(rps), burst),
+		max: rate.Limit(rps),
+		min: rate.Limit(rps / 20),
+	}
+}
+
+// Wait blocks until a request may be sent or the context is done.
+func (l *AdaptiveLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	pause := time.Until(l.pausedUntil)
+	l.mu.Unlock()
+	if pause > 0 {
+		t := time.NewTimer(pause)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+	return l.lim.Wait(ctx)
+}
+
+// ObserveResponse adapts the rate based on the status of a response.
+func (l *AdaptiveLimiter) ObserveResponse(res *http.Response) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if res.StatusCode == http.StatusTooManyRequests {
+		l.successes = 0
+		limit := l.lim.Limit() / 2
+		if limit < l.min {
+			limit = l.min
+		}
+		l.lim.SetLimit(limit)
+		if ra := retryAfter(res); ra > 0 {
+			l.pausedUntil = time.Now().Add(ra)
+		}
+		return
+	}
+	if res.StatusCode >= http.StatusBadRequest || l.lim.Limit() >= l.max {
+		return
+	}
+	l.successes++
+	if l.successes >= adaptiveRecovery {
+		l.successes = 0
+		limit := l.lim.Limit() * 1.25
+		if limit > l.max {
+			limit = l.max
+		}
+		l.lim.SetLimit(limit)
+	}
+}
+
+// Limit returns the current number of requests per second allowed by the limiter.
+func (l *AdaptiveLimiter) Limit() float64 {
+	return float64(l.lim.Limit())
+}
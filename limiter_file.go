@@ -0,0 +1,150 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package planhat
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// FileLimiter is a token bucket rate limiter whose state is kept in a file, allowing several
+// processes on the same host to share one budget for a planhat tenant.  Access to the file is
+// coordinated using an advisory lock, so all processes must use the same path, rate and burst.
+//
+// Like AdaptiveLimiter, it pauses all processes when planhat responds with 429 Too Many Requests
+// and a Retry-After header.
+type FileLimiter struct {
+	mu    sync.Mutex
+	f     *os.File
+	rps   float64
+	burst float64
+}
+
+// fileLimiterState is the state stored in the file, encoded as three 64 bit values.
+type fileLimiterState struct {
+	tokens      float64
+	last        int64 // unix nanoseconds of the last update
+	pausedUntil int64 // unix nanoseconds
+}
+
+const fileLimiterStateSize = 24
+
+// NewFileLimiter opens or creates the file at path and returns a FileLimiter allowing rps requests
+// per second with the given burst across all processes using it.
+func NewFileLimiter(path string, rps float64, burst int) (*FileLimiter, error) {
+	if rps <= 0 || burst < 1 {
+		return nil, errors.New("rate limit requires a positive rate and burst")
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileLimiter{f: f, rps: rps, burst: float64(burst)}, nil
+}
+
+// Close closes the underlying file.
+func (l *FileLimiter) Close() error {
+	return l.f.Close()
+}
+
+// Wait blocks until a request may be sent or the context is done.
+func (l *FileLimiter) Wait(ctx context.Context) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		delay, err := l.take()
+		if err != nil {
+			return err
+		}
+		if delay == 0 {
+			return nil
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return context.DeadlineExceeded
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// take attempts to remove a token from the bucket, returning how long to wait before trying again
+// if none are available.
+func (l *FileLimiter) take() (time.Duration, error) {
+	var delay time.Duration
+	err := l.update(func(s *fileLimiterState, now time.Time) {
+		if pause := s.pausedUntil - now.UnixNano(); pause > 0 {
+			delay = time.Duration(pause)
+			return
+		}
+		if s.tokens >= 1 {
+			s.tokens--
+			return
+		}
+		delay = time.Duration((1 - s.tokens) / l.rps * float64(time.Second))
+	})
+	return delay, err
+}
+
+// ObserveResponse pauses all processes sharing the limiter when planhat asks us to slow down.
+func (l *FileLimiter) ObserveResponse(res *http.Response) {
+	if res.StatusCode != http.StatusTooManyRequests {
+		return
+	}
+	ra := retryAfter(res)
+	if ra == 0 {
+		return
+	}
+	l.update(func(s *fileLimiterState, now time.Time) {
+		if until := now.Add(ra).UnixNano(); until > s.pausedUntil {
+			s.pausedUntil = until
+		}
+	})
+}
+
+// update locks the file, refills the bucket, applies fn to the state and writes it back.
+func (l *FileLimiter) update(fn func(s *fileLimiterState, now time.Time)) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fd := int(l.f.Fd())
+	if err := syscall.Flock(fd, syscall.LOCK_EX); err != nil {
+		return err
+	}
+	defer syscall.Flock(fd, syscall.LOCK_UN)
+
+	now := time.Now()
+	var buf [fileLimiterStateSize]byte
+	s := fileLimiterState{tokens: l.burst, last: now.UnixNano()}
+	if _, err := l.f.ReadAt(buf[:], 0); err == nil {
+		s.tokens = math.Float64frombits(binary.LittleEndian.Uint64(buf[0:]))
+		s.last = int64(binary.LittleEndian.Uint64(buf[8:]))
+		s.pausedUntil = int64(binary.LittleEndian.Uint64(buf[16:]))
+	} else if err != io.EOF {
+		return err
+	}
+
+	if elapsed := now.UnixNano() - s.last; elapsed > 0 {
+		s.tokens = math.Min(l.burst, s.tokens+time.Duration(elapsed).Seconds()*l.rps)
+		s.last = now.UnixNano()
+	}
+	fn(&s, now)
+
+	binary.LittleEndian.PutUint64(buf[0:], math.Float64bits(s.tokens))
+	binary.LittleEndian.PutUint64(buf[8:], uint64(s.last))
+	binary.LittleEndian.PutUint64(buf[16:], uint64(s.pausedUntil))
+	_, err := l.f.WriteAt(buf[:], 0)
+	return err
+}
@@ -0,0 +1,27 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package planhat
+
+import (
+	"context"
+	"errors"
+)
+
+// FileLimiter is a token bucket rate limiter shared between processes using a lock file.  It is not
+// supported on this platform.
+type FileLimiter struct{}
+
+// NewFileLimiter returns an error as file locking is not supported on this platform.
+func NewFileLimiter(path string, rps float64, burst int) (*FileLimiter, error) {
+	return nil, errors.ErrUnsupported
+}
+
+// Close does nothing.
+func (l *FileLimiter) Close() error {
+	return nil
+}
+
+// Wait returns an error as file locking is not supported on this platform.
+func (l *FileLimiter) Wait(ctx context.Context) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,92 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package planhat
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+const fileLimiterHelperEnv = "PLANHAT_FILE_LIMITER_HELPER"
+
+// TestFileLimiter_HelperProcess is run as a child process by TestFileLimiter_MultiProcess.
+func TestFileLimiter_HelperProcess(t *testing.T) {
+	path := os.Getenv(fileLimiterHelperEnv)
+	if path == "" {
+		t.Skip("helper process only")
+	}
+	l, err := NewFileLimiter(path, 50, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	for i := 0; i < 10; i++ {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFileLimiter_MultiProcess(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping multi process test in short mode")
+	}
+	path := filepath.Join(t.TempDir(), "planhat.lim")
+	const procs = 3
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	errs := make(chan error, procs)
+	for i := 0; i < procs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cmd := exec.Command(os.Args[0], "-test.run=^TestFileLimiter_HelperProcess$")
+			cmd.Env = append(os.Environ(), fileLimiterHelperEnv+"="+path)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				errs <- errors.New(string(out))
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("helper process failed: %v", err)
+	}
+
+	// 30 requests at 50 per second with a burst of 1 can't complete in under 580ms if the bucket is
+	// shared, whereas each process alone would finish its 10 requests in around 180ms.
+	if elapsed := time.Since(start); elapsed < 560*time.Millisecond {
+		t.Errorf("processes finished in %v; expected the budget to be shared", elapsed)
+	}
+}
+
+func TestFileLimiter_Pause(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "planhat.lim")
+	l1, _ := NewFileLimiter(path, 1000, 10)
+	defer l1.Close()
+	l2, err := NewFileLimiter(path, 1000, 10)
+	if err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+	defer l2.Close()
+
+	if err := l2.Wait(context.Background()); err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+	l1.ObserveResponse(&http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {strconv.Itoa(60)}}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l2.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error: %v; want %v while paused by another limiter", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,104 @@
+package planhat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestLimiter_CancelledContext(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprint(w, `[]`)
+	}))
+	defer ts.Close()
+
+	// A limiter with no tokens available forces a wait.
+	c, _ := New("key", WithBaseURL(ts.URL), WithRateLimiter(rate.NewLimiter(0.001, 1)))
+	c.CompanyService.List(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.CompanyService.List(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error: %v; want %v", err, context.Canceled)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.CompanyService.List(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error: %v; want %v", err, context.DeadlineExceeded)
+	}
+	if calls != 1 {
+		t.Errorf("got %d calls; want 1", calls)
+	}
+}
+
+func TestLimiter_SeparateBudgets(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{}`)
+	}))
+	defer ts.Close()
+
+	api := &countingLimiter{}
+	metrics := &countingLimiter{}
+	opts := []Option{
+		WithBaseURL(ts.URL),
+		WithMetricsURL(ts.URL + "/dimensiondata"),
+		WithTenantUUID("tenant"),
+		WithRateLimiter(api),
+		WithMetricsRateLimiter(metrics),
+	}
+	// Two clients sharing the same limiters.
+	c1, _ := New("key", opts...)
+	c2, _ := New("key", opts...)
+	c1.CompanyService.Get(context.Background(), "1")
+	c2.AssetService.Get(context.Background(), "1")
+	c2.MetricsService.BulkUpsert(context.Background(), []Metric{{DimensionID: String("d"), Value: Float64(1), ExternalID: String("e")}})
+	if api.n != 2 || metrics.n != 1 {
+		t.Errorf("got %d api and %d metrics waits; want 2 and 1", api.n, metrics.n)
+	}
+}
+
+type countingLimiter struct{ n int }
+
+func (l *countingLimiter) Wait(ctx context.Context) error {
+	l.n++
+	return nil
+}
+
+func TestAdaptiveLimiter(t *testing.T) {
+	l := NewAdaptiveLimiter(100, 1)
+	throttled := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+	ok := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+
+	l.ObserveResponse(throttled)
+	if l.Limit() != 50 {
+		t.Errorf("got limit %v after 429; want 50", l.Limit())
+	}
+	for i := 0; i < 10; i++ {
+		l.ObserveResponse(throttled)
+	}
+	if l.Limit() != 5 {
+		t.Errorf("got limit %v after repeated 429s; want minimum of 5", l.Limit())
+	}
+	for i := 0; i < 1000; i++ {
+		l.ObserveResponse(ok)
+	}
+	if l.Limit() != 100 {
+		t.Errorf("got limit %v after recovery; want 100", l.Limit())
+	}
+
+	throttled.Header.Set("Retry-After", "60")
+	l.ObserveResponse(throttled)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error: %v; want %v while paused", err, context.DeadlineExceeded)
+	}
+}
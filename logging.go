@@ -0,0 +1,166 @@
+package planhat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxLoggedBody is the maximum number of bytes of a request or response body that will be logged.
+const maxLoggedBody = 64 << 10
+
+const redacted = "[REDACTED]"
+
+// logConfig holds the logging configuration for a client.
+type logConfig struct {
+	bodies bool
+	redact map[string]bool
+}
+
+// WithLogBodies enables logging of request headers and request and response bodies at debug level.
+// It requires a logger set using WithLogger.  The Authorization header is always redacted, as are the
+// values of any fields set using WithRedactedFields.  Bodies over 64KB are truncated.  Response bodies
+// are read into memory to be logged, so streaming methods such as LeanListEach lose their benefit.
+func WithLogBodies(enabled bool) Option {
+	return func(c *Client) error {
+		c.logging.bodies = enabled
+		return nil
+	}
+}
+
+// WithRedactedFields sets JSON field names whose values are redacted wherever they appear in logged
+// bodies, e.g. sensitive custom fields.  Matching is case insensitive.
+func WithRedactedFields(fields ...string) Option {
+	return func(c *Client) error {
+		if c.logging.redact == nil {
+			c.logging.redact = map[string]bool{}
+		}
+		for _, f := range fields {
+			c.logging.redact[strings.ToLower(f)] = true
+		}
+		return nil
+	}
+}
+
+// logAttempt logs the outcome of a single attempt at sending a request.
+func (c *Client) logAttempt(ctx context.Context, req *http.Request, res *http.Response, err error, attempt int, d time.Duration) {
+	if c.logger == nil {
+		return
+	}
+	attrs := []any{
+		"method", req.Method,
+		"url", c.redactURL(req.URL.String()),
+		"attempt", attempt,
+		"duration", d,
+		"request_size", req.ContentLength,
+	}
+	level := slog.LevelDebug
+	if err != nil {
+		level = slog.LevelWarn
+		attrs = append(attrs, "error", err)
+	} else {
+		if res.StatusCode >= http.StatusBadRequest {
+			level = slog.LevelWarn
+		}
+		attrs = append(attrs, "status", res.StatusCode, "response_size", res.ContentLength)
+	}
+	c.logger.Log(ctx, level, "planhat: request", attrs...)
+
+	if !c.logging.bodies || !c.logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	dump := []any{
+		"method", req.Method,
+		"url", c.redactURL(req.URL.String()),
+		"attempt", attempt,
+		"headers", redactHeaders(req.Header),
+	}
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			var r io.Reader = body
+			if req.Header.Get("Content-Encoding") == "gzip" {
+				r = &gunzipBody{body: body}
+			}
+			b, _ := io.ReadAll(r)
+			body.Close()
+			dump = append(dump, "request_body", c.redactBody(b))
+		}
+	}
+	if res != nil {
+		b, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		res.Body = io.NopCloser(bytes.NewReader(b))
+		if err == nil {
+			dump = append(dump, "response_body", c.redactBody(b))
+		}
+	}
+	c.logger.DebugContext(ctx, "planhat: request body", dump...)
+}
+
+type redactURLKey struct{}
+
+// RedactedURL returns the URL of a request made by the client with the tenant token removed from
+// metrics URLs, for middleware that logs or records it.
+func RedactedURL(req *http.Request) string {
+	if redact, ok := req.Context().Value(redactURLKey{}).(func(string) string); ok {
+		return redact(req.URL.String())
+	}
+	return req.URL.String()
+}
+
+// redactURL removes the tenant token from metrics URLs.
+func (c *Client) redactURL(u string) string {
+	if c.TenantUUID == "" {
+		return u
+	}
+	return strings.ReplaceAll(u, c.TenantUUID, redacted)
+}
+
+// redactHeaders returns a copy of the headers with the Authorization value removed.
+func redactHeaders(h http.Header) http.Header {
+	rh := h.Clone()
+	if rh.Get("Authorization") != "" {
+		rh.Set("Authorization", "Bearer "+redacted)
+	}
+	return rh
+}
+
+// redactBody returns the body as a string, replacing the values of any redacted fields and
+// truncating it to maxLoggedBody.  Bodies that are not valid JSON are not redacted.
+func (c *Client) redactBody(b []byte) string {
+	if len(c.logging.redact) > 0 {
+		var v interface{}
+		if err := json.Unmarshal(b, &v); err == nil {
+			if out, err := json.Marshal(redactValue(v, c.logging.redact)); err == nil {
+				b = out
+			}
+		}
+	}
+	if len(b) > maxLoggedBody {
+		return string(b[:maxLoggedBody]) + "...(truncated)"
+	}
+	return string(b)
+}
+
+func redactValue(v interface{}, fields map[string]bool) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for k, val := range x {
+			if fields[strings.ToLower(k)] {
+				x[k] = redacted
+				continue
+			}
+			x[k] = redactValue(val, fields)
+		}
+	case []interface{}:
+		for i := range x {
+			x[i] = redactValue(x[i], fields)
+		}
+	}
+	return v
+}
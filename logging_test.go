@@ -0,0 +1,67 @@
+package planhat
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLogging_Redaction(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"_id":"1","custom":{"ssn":"123-45-6789","tier":"gold"}}`)
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c, _ := New("secret-api-key",
+		WithBaseURL(ts.URL),
+		WithMetricsURL(ts.URL+"/dimensiondata"),
+		WithTenantUUID("tenant-token"),
+		WithLogger(logger),
+		WithLogBodies(true),
+		WithRedactedFields("SSN"),
+	)
+	co, err := c.CompanyService.Update(context.Background(), "1", Company{Custom: map[string]interface{}{"ssn": "987-65-4321"}})
+	if err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+	if co.Custom["ssn"] != "123-45-6789" {
+		t.Errorf("response body should be unaffected by logging, got custom: %v", co.Custom)
+	}
+	c.MetricsService.BulkUpsert(context.Background(), []Metric{{DimensionID: String("logins"), Value: Float64(1), ExternalID: String("1")}})
+
+	out := buf.String()
+	for _, secret := range []string{"secret-api-key", "123-45-6789", "987-65-4321", "tenant-token"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("log output contains %q: %v", secret, out)
+		}
+	}
+	for _, want := range []string{"attempt=1", "status=200", "method=PUT", "request_size=", "tier", "Bearer [REDACTED]"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log output missing %q: %v", want, out)
+		}
+	}
+}
+
+func TestLogging_Disabled(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	c, _ := New("key", WithBaseURL(ts.URL), WithLogger(logger), WithLogBodies(true))
+	if _, err := c.CompanyService.List(context.Background()); err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected no output at info level, got: %v", buf.String())
+	}
+}
@@ -2,10 +2,8 @@ package planhat
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/http"
-	"strings"
 	"time"
 )
 
@@ -20,6 +18,7 @@ type MetricsListOptions struct {
 	DimID *string `url:"dimid,omitempty"`
 
 	// Days format integer representing the start day period (Days since January 1, 1970, Unix epoch).
+	// Use planhat.EpochDays() to convert a time.Time.
 	From *int `url:"from,omitempty"`
 
 	// Days format integer representing the end day period (Days since January 1, 1970, Unix epoch).
@@ -46,6 +45,7 @@ type Metric struct {
 	// Company (default), EndUser, Asset and Project models are supported
 	Model *string `json:"model,omitempty"`
 	// Pass a valid ISO format date string to specify the date of the event. In none is provided we will use the time the request was received.
+	// Use planhat.MetricDate() to set it from a time.Time.
 	Date *string `json:"date,omitempty"`
 }
 
@@ -63,6 +63,7 @@ type DimensionData struct {
 
 // List returns a list of DimensionData items as per the documentation https://docs.planhat.com/#get_metrics
 func (s *MetricsService) List(ctx context.Context, options ...*MetricsListOptions) ([]*DimensionData, error) {
+	ctx = withOperation(ctx, "MetricsService.List")
 	dd := []*DimensionData{}
 	url := fmt.Sprintf("%s/dimensiondata", s.client.BaseURL)
 	for _, option := range options {
@@ -87,15 +88,18 @@ func (s *MetricsService) List(ctx context.Context, options ...*MetricsListOption
 // be found in the Developer module under the Tokens section.  Set the TenantUUID on the planhat Client.
 // For more information, see the [planhat docs](https://docs.planhat.com/#bulkupsert_metrics)
 func (s *MetricsService) BulkUpsert(ctx context.Context, metrics []Metric) (*UpsertMetricsResponse, error) {
+	ctx = withOperation(ctx, "MetricsService.BulkUpsert")
 	if s.client.TenantUUID == "" {
 		return nil, ErrMissingTenantUUID
 	}
-	url := fmt.Sprintf("%s/%s", s.client.MetricsURL, s.client.TenantUUID)
-	payload, err := json.Marshal(metrics)
-	if err != nil {
+	if s.client.normaliseDimensions {
+		metrics = normaliseDimensions(metrics)
+	}
+	if err := s.client.validateMetrics(metrics); err != nil {
 		return nil, err
 	}
-	req, err := http.NewRequest("POST", url, strings.NewReader(string(payload)))
+	url := fmt.Sprintf("%s/%s", s.client.MetricsURL, s.client.TenantUUID)
+	req, err := s.client.newRequest("POST", url, metrics)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,80 @@
+package planhat
+
+import (
+	"context"
+	"time"
+)
+
+// metricDateLayout is the ISO 8601 format planhat uses for dates.
+const metricDateLayout = "2006-01-02T15:04:05.000Z"
+
+// metricsPageSize is the number of items requested per page by ListRange.
+const metricsPageSize = 5000
+
+// MetricDate returns a pointer to t formatted as an ISO 8601 date in UTC, for use as a Metric Date:
+//
+//	m := planhat.Metric{DimensionID: planhat.String("logins"), Date: planhat.MetricDate(t), ...}
+func MetricDate(t time.Time) *string {
+	return String(t.UTC().Format(metricDateLayout))
+}
+
+// Time returns the metric's Date as a time, or the zero time and false if it isn't set or isn't a
+// valid ISO 8601 date.
+func (m *Metric) Time() (time.Time, bool) {
+	if m == nil || m.Date == nil {
+		return time.Time{}, false
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, *m.Date); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// EpochDays returns the number of whole days between the Unix epoch and t, in UTC, as used by the From
+// and To fields of MetricsListOptions.
+func EpochDays(t time.Time) int {
+	secs := t.Unix()
+	days := secs / 86400
+	if secs%86400 < 0 {
+		days--
+	}
+	return int(days)
+}
+
+// FromEpochDays returns the start of the day n days after the Unix epoch, in UTC.
+func FromEpochDays(n int) time.Time {
+	return time.Unix(int64(n)*86400, 0).UTC()
+}
+
+// ListRange returns all the dimension data between the days of from and to, optionally restricted to
+// a company and dimension if cid and dimID are not empty.  It requests further pages until all the
+// data has been returned.
+func (s *MetricsService) ListRange(ctx context.Context, cid, dimID string, from, to time.Time) ([]*DimensionData, error) {
+	ctx = withOperation(ctx, "MetricsService.ListRange")
+	opts := &MetricsListOptions{
+		From:   Int(EpochDays(from)),
+		To:     Int(EpochDays(to)),
+		Limit:  Int(metricsPageSize),
+		Offset: Int(0),
+	}
+	if cid != "" {
+		opts.CID = String(cid)
+	}
+	if dimID != "" {
+		opts.DimID = String(dimID)
+	}
+	all := []*DimensionData{}
+	for {
+		page, err := s.List(ctx, opts)
+		if err != nil {
+			return all, err
+		}
+		all = append(all, page...)
+		if len(page) < metricsPageSize {
+			return all, nil
+		}
+		*opts.Offset += len(page)
+	}
+}
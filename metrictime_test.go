@@ -0,0 +1,80 @@
+package planhat
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEpochDays(t *testing.T) {
+	tests := []struct {
+		t    time.Time
+		want int
+	}{
+		{time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC), 0},
+		{time.Date(1970, 1, 1, 23, 59, 59, 0, time.UTC), 0},
+		{time.Date(1970, 1, 2, 0, 0, 0, 0, time.UTC), 1},
+		{time.Date(1969, 12, 31, 12, 0, 0, 0, time.UTC), -1},
+		{time.Date(2021, 3, 1, 0, 30, 0, 0, time.FixedZone("CET", 3600)), 18686},
+		{time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC), 18687},
+	}
+	for _, tt := range tests {
+		if got := EpochDays(tt.t); got != tt.want {
+			t.Errorf("EpochDays(%v) = %d, want %d", tt.t, got, tt.want)
+		}
+	}
+	if got := FromEpochDays(18687); !got.Equal(time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("FromEpochDays(18687) = %v", got)
+	}
+}
+
+func TestMetricDate(t *testing.T) {
+	ts := time.Date(2021, 3, 1, 10, 30, 0, 0, time.FixedZone("CET", 3600))
+	d := MetricDate(ts)
+	if *d != "2021-03-01T09:30:00.000Z" {
+		t.Errorf("MetricDate = %s", *d)
+	}
+	m := Metric{Date: d}
+	if got, ok := m.Time(); !ok || !got.Equal(ts) {
+		t.Errorf("Time = %v, %v", got, ok)
+	}
+	if _, ok := (&Metric{Date: String("soon")}).Time(); ok {
+		t.Error("expected invalid date")
+	}
+}
+
+func TestListRange(t *testing.T) {
+	const total = metricsPageSize + 10
+	var got []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		got = append(got, q.Encode())
+		offset, _ := strconv.Atoi(q.Get("offset"))
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		var items []string
+		for i := offset; i < total && i < offset+limit; i++ {
+			items = append(items, fmt.Sprintf(`{"_id":"%d"}`, i))
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(items, ","))
+	}))
+	defer ts.Close()
+
+	c, _ := New("key", WithBaseURL(ts.URL))
+	from := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	dd, err := c.MetricsService.ListRange(context.Background(), "c1", "", from, from.AddDate(0, 0, 7))
+	if err != nil || len(dd) != total || dd[total-1].ID != fmt.Sprint(total-1) {
+		t.Fatalf("ListRange = %d items, %v", len(dd), err)
+	}
+	want := []string{
+		"cid=c1&from=18687&limit=5000&offset=0&to=18694",
+		"cid=c1&from=18687&limit=5000&offset=5000&to=18694",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got queries %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,69 @@
+package planhat
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// RoundTripFunc sends a request to planhat and returns the response.
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+// Middleware wraps a RoundTripFunc to add behaviour around each API call, such as auditing, header
+// injection, response capture, metrics or custom authentication.
+//
+// Middleware is invoked once per API call.  Rate limiting and any retries configured with WithRetry
+// happen inside the chain, so the response seen by a middleware is the final one.  The request
+// already carries the Authorization and other standard headers, which a middleware may override.
+// A middleware that reads the response body must replace it so the client can still decode it.
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// WithMiddleware adds middleware to the client.  The first middleware provided is the outermost,
+// meaning it sees the request first and the response last.  It may be used multiple times.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(c *Client) error {
+		c.middleware = append(c.middleware, mw...)
+		return nil
+	}
+}
+
+// chain builds the round trip for the client by wrapping the transport with its middleware.
+func (c *Client) chain() RoundTripFunc {
+	rt := func(req *http.Request) (*http.Response, error) {
+		return c.do(req.Context(), req)
+	}
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		rt = c.middleware[i](rt)
+	}
+	return rt
+}
+
+// LoggingMiddleware logs the method, URL, status and duration of each API call to the given logger.
+// Successful calls are logged at debug level and failures at warn level.  The tenant token is
+// redacted from metrics URLs.
+func LoggingMiddleware(logger *slog.Logger) Middleware {
+	return TimingMiddleware(func(req *http.Request, res *http.Response, err error, d time.Duration) {
+		attrs := []any{"method", req.Method, "url", RedactedURL(req), "duration", d}
+		switch {
+		case err != nil:
+			logger.WarnContext(req.Context(), "planhat: request failed", append(attrs, "error", err)...)
+		case res.StatusCode >= http.StatusBadRequest:
+			logger.WarnContext(req.Context(), "planhat: request failed", append(attrs, "status", res.StatusCode)...)
+		default:
+			logger.DebugContext(req.Context(), "planhat: request", append(attrs, "status", res.StatusCode)...)
+		}
+	})
+}
+
+// TimingMiddleware calls observe with the outcome and duration of each API call, including any time
+// spent waiting on the rate limiter and retrying.
+func TimingMiddleware(observe func(req *http.Request, res *http.Response, err error, d time.Duration)) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			res, err := next(req)
+			observe(req, res, err, time.Since(start))
+			return res, err
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package planhat
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMiddleware_Order(t *testing.T) {
+	var gotHeader string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Get("X-Audit")
+		fmt.Fprint(w, `{"_id":"1"}`)
+	}))
+	defer ts.Close()
+
+	var calls []string
+	mw := func(name string) Middleware {
+		return func(next RoundTripFunc) RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				calls = append(calls, name+" before")
+				req.Header.Set("X-Audit", req.Header.Get("X-Audit")+name)
+				res, err := next(req)
+				calls = append(calls, name+" after")
+				return res, err
+			}
+		}
+	}
+	c, _ := New("key", WithBaseURL(ts.URL), WithMiddleware(mw("a")), WithMiddleware(mw("b")))
+	if _, err := c.CompanyService.Get(context.Background(), "1"); err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+	want := "a before,b before,b after,a after"
+	if got := strings.Join(calls, ","); got != want {
+		t.Errorf("got: %v; want %v", got, want)
+	}
+	if gotHeader != "ab" {
+		t.Errorf("got header: %v; want ab", gotHeader)
+	}
+}
+
+func TestMiddleware_Builtin(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	var timed time.Duration
+	var status int
+	timing := TimingMiddleware(func(req *http.Request, res *http.Response, err error, d time.Duration) {
+		timed, status = d, res.StatusCode
+	})
+	c, _ := New("key", WithBaseURL(ts.URL), WithMetricsURL(ts.URL+"/dimensiondata"), WithTenantUUID("secret-tenant"),
+		WithMiddleware(LoggingMiddleware(logger), timing))
+	if _, err := c.CompanyService.Get(context.Background(), "1"); err != ErrNotFound {
+		t.Errorf("got error: %v; want %v", err, ErrNotFound)
+	}
+	if timed <= 0 || status != http.StatusNotFound {
+		t.Errorf("got duration %v and status %d; want positive duration and 404", timed, status)
+	}
+	if out := buf.String(); !strings.Contains(out, "status=404") || !strings.Contains(out, "method=GET") {
+		t.Errorf("unexpected log output: %v", out)
+	}
+
+	metrics := []Metric{{DimensionID: String("logins"), Value: Float64(1), ExternalID: String("a")}}
+	if _, err := c.MetricsService.BulkUpsert(context.Background(), metrics); err != ErrNotFound {
+		t.Errorf("got error: %v; want %v", err, ErrNotFound)
+	}
+	if out := buf.String(); strings.Contains(out, "secret-tenant") || !strings.Contains(out, "/dimensiondata/[REDACTED]") {
+		t.Errorf("tenant token not redacted: %v", out)
+	}
+}
@@ -0,0 +1,128 @@
+package planhat
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Option configures a Client created using New.
+type Option func(*Client) error
+
+// WithCluster sets the planhat cluster to use, e.g. "eu3" becomes https://api-eu3.planhat.com.
+// An empty string uses the default https://api.planhat.com.
+func WithCluster(cluster string) Option {
+	return func(c *Client) error {
+		c.BaseURL = clusterURL(cluster)
+		return nil
+	}
+}
+
+// WithBaseURL overrides the base URL for the Planhat API, for example to point the client at a
+// local mock server or proxy.  Options are applied in order, so the last of WithCluster and
+// WithBaseURL wins.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) error {
+		if baseURL == "" {
+			return errors.New("base url required")
+		}
+		c.BaseURL = strings.TrimSuffix(baseURL, "/")
+		return nil
+	}
+}
+
+// WithMetricsURL overrides the URL used for pushing metrics, which defaults to
+// https://analytics.planhat.com/dimensiondata.
+func WithMetricsURL(metricsURL string) Option {
+	return func(c *Client) error {
+		if metricsURL == "" {
+			return errors.New("metrics url required")
+		}
+		c.MetricsURL = strings.TrimSuffix(metricsURL, "/")
+		return nil
+	}
+}
+
+// WithHTTPClient sets the http client used to make requests.  Passing nil keeps the default
+// client, which has a 10 second timeout.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) error {
+		if client != nil {
+			c.HTTPClient = client
+		}
+		return nil
+	}
+}
+
+// WithRateLimit sets the number of requests per second and the burst size allowed by the client for
+// the main API, using an AdaptiveLimiter.  The default is 150 requests per second with a burst of 1.
+// Use WithRateLimiter to share a limiter between clients.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(c *Client) error {
+		if rps <= 0 || burst < 1 {
+			return errors.New("rate limit requires a positive rate and burst")
+		}
+		c.lim = NewAdaptiveLimiter(rps, burst)
+		return nil
+	}
+}
+
+// WithTenantUUID sets the tenant token used for pushing metrics.
+func WithTenantUUID(uuid string) Option {
+	return func(c *Client) error {
+		c.TenantUUID = uuid
+		return nil
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with each request.
+func WithUserAgent(ua string) Option {
+	return func(c *Client) error {
+		c.UserAgent = ua
+		return nil
+	}
+}
+
+// WithBatchConcurrency sets the number of requests GetMany makes at once.  The default is 8.
+// Requests are still subject to the rate limiter.
+func WithBatchConcurrency(n int) Option {
+	return func(c *Client) error {
+		if n < 1 {
+			return errors.New("batch concurrency must be at least 1")
+		}
+		c.batch = n
+		return nil
+	}
+}
+
+// WithRetry enables retrying of failed requests up to maxRetries times.  The wait between attempts
+// starts at minBackoff and doubles on each retry up to maxBackoff, unless planhat asks us to wait
+// longer using a Retry-After header.
+//
+// Rate limited (429) requests are always retried.  Server errors and network errors are only
+// retried for idempotent methods (GET, PUT and DELETE) to avoid creating duplicate records.
+func WithRetry(maxRetries int, minBackoff, maxBackoff time.Duration) Option {
+	return func(c *Client) error {
+		if maxRetries < 0 || minBackoff < 0 || maxBackoff < minBackoff {
+			return errors.New("invalid retry configuration")
+		}
+		c.retry = retryPolicy{
+			maxRetries: maxRetries,
+			minBackoff: minBackoff,
+			maxBackoff: maxBackoff,
+		}
+		return nil
+	}
+}
+
+// WithLogger sets the logger used by the client.  By default nothing is logged.  Each attempt at a
+// request is logged with its method, URL, status, duration, attempt number and payload sizes.  The
+// API key is never logged, and the tenant token is redacted from metrics URLs.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) error {
+		c.logger = logger
+		return nil
+	}
+}
@@ -0,0 +1,127 @@
+package planhat
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewClient_Defaults(t *testing.T) {
+	tests := []struct {
+		cluster string
+		want    string
+	}{
+		{"", "https://api.planhat.com"},
+		{"eu3", "https://api-eu3.planhat.com"},
+	}
+	for _, tt := range tests {
+		c, err := NewClient("key", tt.cluster, nil)
+		if err != nil {
+			t.Fatalf("didn't expect error creating client: %v", err)
+		}
+		if c.BaseURL != tt.want {
+			t.Errorf("got: %v; want %v", c.BaseURL, tt.want)
+		}
+		if c.HTTPClient == nil || c.HTTPClient.Timeout != 10*time.Second {
+			t.Errorf("expected default http client with 10 second timeout")
+		}
+	}
+	if _, err := NewClient("", "", nil); err == nil {
+		t.Errorf("expected error for missing api key")
+	}
+}
+
+func TestNew_WithBaseURL(t *testing.T) {
+	var gotAuth, gotUA, gotPath string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotUA = r.Header.Get("User-Agent")
+		gotPath = r.URL.Path
+		fmt.Fprint(w, `[{"_id":"1","name":"Acme"}]`)
+	}))
+	defer ts.Close()
+
+	c, err := New("key", WithCluster("eu3"), WithBaseURL(ts.URL+"/"), WithUserAgent("tests/1.0"), WithTenantUUID("tenant"))
+	if err != nil {
+		t.Fatalf("didn't expect error creating client: %v", err)
+	}
+	if c.TenantUUID != "tenant" {
+		t.Errorf("got tenant: %v; want tenant", c.TenantUUID)
+	}
+	companies, err := c.CompanyService.List(context.Background())
+	if err != nil {
+		t.Fatalf("didn't expect error listing companies: %v", err)
+	}
+	if len(companies) != 1 || companies[0].GetName() != "Acme" {
+		t.Errorf("unexpected companies: %+v", companies)
+	}
+	if gotPath != "/companies" {
+		t.Errorf("got path: %v; want /companies", gotPath)
+	}
+	if gotAuth != "Bearer key" {
+		t.Errorf("got authorization: %v; want Bearer key", gotAuth)
+	}
+	if gotUA != "tests/1.0" {
+		t.Errorf("got user agent: %v; want tests/1.0", gotUA)
+	}
+}
+
+func TestNew_InvalidOptions(t *testing.T) {
+	opts := []Option{
+		WithBaseURL(""),
+		WithMetricsURL(""),
+		WithRateLimit(0, 1),
+		WithRetry(-1, 0, 0),
+		WithRetry(1, time.Second, time.Millisecond),
+	}
+	for i, opt := range opts {
+		if _, err := New("key", opt); err == nil {
+			t.Errorf("option %d: expected error", i)
+		}
+	}
+}
+
+func TestNew_WithRetry(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"_id":"1"}`)
+	}))
+	defer ts.Close()
+
+	t.Run("retries until success", func(t *testing.T) {
+		c, _ := New("key", WithBaseURL(ts.URL), WithRetry(3, time.Millisecond, 5*time.Millisecond))
+		co, err := c.CompanyService.Get(context.Background(), "1")
+		if err != nil {
+			t.Fatalf("didn't expect error: %v", err)
+		}
+		if co.GetID() != "1" || calls != 3 {
+			t.Errorf("got id %v after %d calls; want 1 after 3", co.GetID(), calls)
+		}
+	})
+	t.Run("gives up after max retries", func(t *testing.T) {
+		calls = 0
+		c, _ := New("key", WithBaseURL(ts.URL), WithRetry(1, time.Millisecond, time.Millisecond))
+		if _, err := c.CompanyService.Get(context.Background(), "1"); err != ErrUnknown {
+			t.Errorf("got error: %v; want %v", err, ErrUnknown)
+		}
+		if calls != 2 {
+			t.Errorf("got %d calls; want 2", calls)
+		}
+	})
+	t.Run("does not retry creates on server errors", func(t *testing.T) {
+		calls = 0
+		c, _ := New("key", WithBaseURL(ts.URL), WithRetry(3, time.Millisecond, time.Millisecond))
+		c.CompanyService.Create(context.Background(), Company{Name: String("Acme")})
+		if calls != 1 {
+			t.Errorf("got %d calls; want 1", calls)
+		}
+	})
+}
@@ -0,0 +1,195 @@
+// Package otelplanhat provides OpenTelemetry instrumentation for the planhat client.
+//
+// Add the middleware when creating the client to record a span and metrics for every API call:
+//
+//	ph, err := planhat.New(apikey, planhat.WithMiddleware(otelplanhat.Middleware()))
+//
+// Spans are named after the service method, e.g. "CompanyService.BulkUpsert", and record the time
+// spent waiting on the rate limiter and any retries as events.  The middleware should be the first
+// one provided so that its span covers the whole call.
+package otelplanhat
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/darrenparkinson/planhat"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope name used for the tracer and meter.
+const ScopeName = "github.com/darrenparkinson/planhat/otelplanhat"
+
+// OperationKey is the attribute key used to record the planhat service method.
+const OperationKey = attribute.Key("planhat.operation")
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+}
+
+// Option configures the instrumentation.
+type Option func(*config)
+
+// WithTracerProvider sets the tracer provider to use.  The global provider is used by default.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = tp
+	}
+}
+
+// WithMeterProvider sets the meter provider to use.  The global provider is used by default.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(c *config) {
+		c.meterProvider = mp
+	}
+}
+
+type instruments struct {
+	calls    metric.Int64Counter
+	errors   metric.Int64Counter
+	retries  metric.Int64Counter
+	duration metric.Float64Histogram
+	wait     metric.Float64Histogram
+}
+
+func newInstruments(m metric.Meter) (*instruments, error) {
+	var (
+		ins instruments
+		err error
+	)
+	if ins.calls, err = m.Int64Counter("planhat.client.calls",
+		metric.WithDescription("Number of planhat API calls."),
+		metric.WithUnit("{call}")); err != nil {
+		return nil, err
+	}
+	if ins.errors, err = m.Int64Counter("planhat.client.errors",
+		metric.WithDescription("Number of planhat API calls that failed."),
+		metric.WithUnit("{call}")); err != nil {
+		return nil, err
+	}
+	if ins.retries, err = m.Int64Counter("planhat.client.retries",
+		metric.WithDescription("Number of retried attempts at planhat API calls."),
+		metric.WithUnit("{attempt}")); err != nil {
+		return nil, err
+	}
+	if ins.duration, err = m.Float64Histogram("planhat.client.duration",
+		metric.WithDescription("Duration of planhat API calls, including rate limiting and retries."),
+		metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if ins.wait, err = m.Float64Histogram("planhat.client.rate_limit.wait",
+		metric.WithDescription("Time spent waiting on the rate limiter per call."),
+		metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	return &ins, nil
+}
+
+// Middleware returns planhat middleware that records a span, counters and histograms for each call.
+func Middleware(opts ...Option) planhat.Middleware {
+	cfg := config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	tracer := cfg.tracerProvider.Tracer(ScopeName)
+	ins, err := newInstruments(cfg.meterProvider.Meter(ScopeName))
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	return func(next planhat.RoundTripFunc) planhat.RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			ctx := req.Context()
+			op := planhat.OperationFromContext(ctx)
+			name := op
+			if name == "" {
+				name = "planhat " + req.Method
+			}
+			attrs := []attribute.KeyValue{
+				OperationKey.String(op),
+				semconv.HTTPRequestMethodKey.String(req.Method),
+				semconv.ServerAddress(req.URL.Hostname()),
+				semconv.URLScheme(req.URL.Scheme),
+			}
+			ctx, span := tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+			defer span.End()
+
+			var waited time.Duration
+			prev := planhat.ContextClientTrace(ctx)
+			ct := &planhat.ClientTrace{
+				RateLimitWait: func(attempt int, d time.Duration) {
+					waited += d
+					if d >= time.Millisecond {
+						span.AddEvent("rate_limit.wait", trace.WithAttributes(
+							attribute.Int("planhat.attempt", attempt),
+							attribute.Float64("planhat.wait_seconds", d.Seconds()),
+						))
+					}
+					if prev != nil && prev.RateLimitWait != nil {
+						prev.RateLimitWait(attempt, d)
+					}
+				},
+				Retry: func(attempt int, wait time.Duration, res *http.Response, err error) {
+					eattrs := []attribute.KeyValue{
+						attribute.Int("planhat.attempt", attempt),
+						attribute.Float64("planhat.wait_seconds", wait.Seconds()),
+					}
+					if err != nil {
+						eattrs = append(eattrs, attribute.String("planhat.error", err.Error()))
+					} else {
+						eattrs = append(eattrs, semconv.HTTPResponseStatusCode(res.StatusCode))
+					}
+					span.AddEvent("retry", trace.WithAttributes(eattrs...))
+					if ins != nil {
+						ins.retries.Add(ctx, 1, metric.WithAttributes(OperationKey.String(op)))
+					}
+					if prev != nil && prev.Retry != nil {
+						prev.Retry(attempt, wait, res, err)
+					}
+				},
+			}
+
+			start := time.Now()
+			res, err := next(req.WithContext(planhat.WithClientTrace(ctx, ct)))
+			elapsed := time.Since(start)
+
+			mattrs := []attribute.KeyValue{OperationKey.String(op), semconv.HTTPRequestMethodKey.String(req.Method)}
+			failed := false
+			switch {
+			case err != nil:
+				failed = true
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				mattrs = append(mattrs, semconv.ErrorTypeKey.String("error"))
+			default:
+				span.SetAttributes(semconv.HTTPResponseStatusCode(res.StatusCode))
+				mattrs = append(mattrs, semconv.HTTPResponseStatusCode(res.StatusCode))
+				if res.StatusCode >= http.StatusBadRequest {
+					failed = true
+					span.SetStatus(codes.Error, http.StatusText(res.StatusCode))
+				}
+			}
+			span.SetAttributes(attribute.Float64("planhat.rate_limit.wait_seconds", waited.Seconds()))
+
+			if ins != nil {
+				ma := metric.WithAttributes(mattrs...)
+				ins.calls.Add(ctx, 1, ma)
+				if failed {
+					ins.errors.Add(ctx, 1, ma)
+				}
+				ins.duration.Record(ctx, elapsed.Seconds(), ma)
+				ins.wait.Record(ctx, waited.Seconds(), metric.WithAttributes(OperationKey.String(op)))
+			}
+			return res, err
+		}
+	}
+}
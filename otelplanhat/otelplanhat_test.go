@@ -0,0 +1,105 @@
+package otelplanhat
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/darrenparkinson/planhat"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `{"created":1}`)
+	}))
+	defer ts.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	c, err := planhat.New("key",
+		planhat.WithBaseURL(ts.URL),
+		planhat.WithRetry(2, time.Millisecond, time.Millisecond),
+		planhat.WithMiddleware(Middleware(WithTracerProvider(tp), WithMeterProvider(mp))),
+	)
+	if err != nil {
+		t.Fatalf("didn't expect error creating client: %v", err)
+	}
+	if _, err := c.CompanyService.BulkUpsert(context.Background(), []planhat.Company{{Name: planhat.String("Acme")}}); err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans; want 1", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "CompanyService.BulkUpsert" {
+		t.Errorf("got span name: %v; want CompanyService.BulkUpsert", span.Name)
+	}
+	if span.Status.Code == codes.Error {
+		t.Errorf("didn't expect error status")
+	}
+	retries := 0
+	for _, e := range span.Events {
+		if e.Name == "retry" {
+			retries++
+		}
+	}
+	if retries != 1 {
+		t.Errorf("got %d retry events; want 1", retries)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("didn't expect error collecting metrics: %v", err)
+	}
+	got := map[string]bool{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			got[m.Name] = true
+		}
+	}
+	for _, name := range []string{"planhat.client.calls", "planhat.client.retries", "planhat.client.duration", "planhat.client.rate_limit.wait"} {
+		if !got[name] {
+			t.Errorf("missing metric %v", name)
+		}
+	}
+}
+
+func TestMiddleware_Error(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	c, _ := planhat.New("key", planhat.WithBaseURL(ts.URL), planhat.WithMiddleware(Middleware(WithTracerProvider(tp))))
+	if _, err := c.AssetService.GetByExternalID(context.Background(), "ext"); err != planhat.ErrNotFound {
+		t.Errorf("got error: %v; want %v", err, planhat.ErrNotFound)
+	}
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Name != "AssetService.GetByExternalID" {
+		t.Fatalf("unexpected spans: %+v", spans)
+	}
+	if spans[0].Status.Code != codes.Error {
+		t.Errorf("got status %v; want error", spans[0].Status.Code)
+	}
+}
@@ -0,0 +1,290 @@
+// Copyright 2021 The go-planhat AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+// Code generated by gen-updates; DO NOT EDIT.
+package planhat
+
+import (
+	"time"
+)
+
+// SetCompanyID sets the companyId field.
+func (u *AssetUpdate) SetCompanyID(v string) *AssetUpdate {
+	return u.Set("companyId", v)
+}
+
+// ClearCompanyID sets the companyId field to null.
+func (u *AssetUpdate) ClearCompanyID() *AssetUpdate {
+	return u.Clear("companyId")
+}
+
+// SetExternalID sets the externalId field.
+func (u *AssetUpdate) SetExternalID(v string) *AssetUpdate {
+	return u.Set("externalId", v)
+}
+
+// ClearExternalID sets the externalId field to null.
+func (u *AssetUpdate) ClearExternalID() *AssetUpdate {
+	return u.Clear("externalId")
+}
+
+// SetName sets the name field.
+func (u *AssetUpdate) SetName(v string) *AssetUpdate {
+	return u.Set("name", v)
+}
+
+// ClearName sets the name field to null.
+func (u *AssetUpdate) ClearName() *AssetUpdate {
+	return u.Clear("name")
+}
+
+// SetSourceID sets the sourceId field.
+func (u *AssetUpdate) SetSourceID(v string) *AssetUpdate {
+	return u.Set("sourceId", v)
+}
+
+// ClearSourceID sets the sourceId field to null.
+func (u *AssetUpdate) ClearSourceID() *AssetUpdate {
+	return u.Clear("sourceId")
+}
+
+// SetCSMScore sets the csmScore field.
+func (u *CompanyUpdate) SetCSMScore(v int) *CompanyUpdate {
+	return u.Set("csmScore", v)
+}
+
+// ClearCSMScore sets the csmScore field to null.
+func (u *CompanyUpdate) ClearCSMScore() *CompanyUpdate {
+	return u.Clear("csmScore")
+}
+
+// SetCoOwner sets the coOwner field.
+func (u *CompanyUpdate) SetCoOwner(v interface{}) *CompanyUpdate {
+	return u.Set("coOwner", v)
+}
+
+// ClearCoOwner sets the coOwner field to null.
+func (u *CompanyUpdate) ClearCoOwner() *CompanyUpdate {
+	return u.Clear("coOwner")
+}
+
+// SetCustomerFrom sets the customerFrom field.
+func (u *CompanyUpdate) SetCustomerFrom(v time.Time) *CompanyUpdate {
+	return u.Set("customerFrom", v)
+}
+
+// ClearCustomerFrom sets the customerFrom field to null.
+func (u *CompanyUpdate) ClearCustomerFrom() *CompanyUpdate {
+	return u.Clear("customerFrom")
+}
+
+// SetCustomerTo sets the customerTo field.
+func (u *CompanyUpdate) SetCustomerTo(v time.Time) *CompanyUpdate {
+	return u.Set("customerTo", v)
+}
+
+// ClearCustomerTo sets the customerTo field to null.
+func (u *CompanyUpdate) ClearCustomerTo() *CompanyUpdate {
+	return u.Clear("customerTo")
+}
+
+// SetExternalID sets the externalId field.
+func (u *CompanyUpdate) SetExternalID(v string) *CompanyUpdate {
+	return u.Set("externalId", v)
+}
+
+// ClearExternalID sets the externalId field to null.
+func (u *CompanyUpdate) ClearExternalID() *CompanyUpdate {
+	return u.Clear("externalId")
+}
+
+// SetH sets the h field.
+func (u *CompanyUpdate) SetH(v int) *CompanyUpdate {
+	return u.Set("h", v)
+}
+
+// ClearH sets the h field to null.
+func (u *CompanyUpdate) ClearH() *CompanyUpdate {
+	return u.Clear("h")
+}
+
+// SetLastRenewal sets the lastRenewal field.
+func (u *CompanyUpdate) SetLastRenewal(v time.Time) *CompanyUpdate {
+	return u.Set("lastRenewal", v)
+}
+
+// ClearLastRenewal sets the lastRenewal field to null.
+func (u *CompanyUpdate) ClearLastRenewal() *CompanyUpdate {
+	return u.Clear("lastRenewal")
+}
+
+// SetLastTouch sets the lastTouch field.
+func (u *CompanyUpdate) SetLastTouch(v interface{}) *CompanyUpdate {
+	return u.Set("lastTouch", v)
+}
+
+// ClearLastTouch sets the lastTouch field to null.
+func (u *CompanyUpdate) ClearLastTouch() *CompanyUpdate {
+	return u.Clear("lastTouch")
+}
+
+// SetLastTouchType sets the lastTouchType field.
+func (u *CompanyUpdate) SetLastTouchType(v interface{}) *CompanyUpdate {
+	return u.Set("lastTouchType", v)
+}
+
+// ClearLastTouchType sets the lastTouchType field to null.
+func (u *CompanyUpdate) ClearLastTouchType() *CompanyUpdate {
+	return u.Clear("lastTouchType")
+}
+
+// SetLicenses sets the licenses field.
+func (u *CompanyUpdate) SetLicenses(v []License) *CompanyUpdate {
+	return u.Set("licenses", v)
+}
+
+// ClearLicenses sets the licenses field to null.
+func (u *CompanyUpdate) ClearLicenses() *CompanyUpdate {
+	return u.Clear("licenses")
+}
+
+// SetMR sets the mr field.
+func (u *CompanyUpdate) SetMR(v float64) *CompanyUpdate {
+	return u.Set("mr", v)
+}
+
+// ClearMR sets the mr field to null.
+func (u *CompanyUpdate) ClearMR() *CompanyUpdate {
+	return u.Clear("mr")
+}
+
+// SetMRR sets the mrr field.
+func (u *CompanyUpdate) SetMRR(v float64) *CompanyUpdate {
+	return u.Set("mrr", v)
+}
+
+// ClearMRR sets the mrr field to null.
+func (u *CompanyUpdate) ClearMRR() *CompanyUpdate {
+	return u.Clear("mrr")
+}
+
+// SetMRRTotal sets the mrrTotal field.
+func (u *CompanyUpdate) SetMRRTotal(v float64) *CompanyUpdate {
+	return u.Set("mrrTotal", v)
+}
+
+// ClearMRRTotal sets the mrrTotal field to null.
+func (u *CompanyUpdate) ClearMRRTotal() *CompanyUpdate {
+	return u.Clear("mrrTotal")
+}
+
+// SetMRTotal sets the mrTotal field.
+func (u *CompanyUpdate) SetMRTotal(v float64) *CompanyUpdate {
+	return u.Set("mrTotal", v)
+}
+
+// ClearMRTotal sets the mrTotal field to null.
+func (u *CompanyUpdate) ClearMRTotal() *CompanyUpdate {
+	return u.Clear("mrTotal")
+}
+
+// SetNRR30 sets the nrr30 field.
+func (u *CompanyUpdate) SetNRR30(v float64) *CompanyUpdate {
+	return u.Set("nrr30", v)
+}
+
+// ClearNRR30 sets the nrr30 field to null.
+func (u *CompanyUpdate) ClearNRR30() *CompanyUpdate {
+	return u.Clear("nrr30")
+}
+
+// SetNRRTotal sets the nrrTotal field.
+func (u *CompanyUpdate) SetNRRTotal(v float64) *CompanyUpdate {
+	return u.Set("nrrTotal", v)
+}
+
+// ClearNRRTotal sets the nrrTotal field to null.
+func (u *CompanyUpdate) ClearNRRTotal() *CompanyUpdate {
+	return u.Clear("nrrTotal")
+}
+
+// SetName sets the name field.
+func (u *CompanyUpdate) SetName(v string) *CompanyUpdate {
+	return u.Set("name", v)
+}
+
+// ClearName sets the name field to null.
+func (u *CompanyUpdate) ClearName() *CompanyUpdate {
+	return u.Clear("name")
+}
+
+// SetOwner sets the owner field.
+func (u *CompanyUpdate) SetOwner(v interface{}) *CompanyUpdate {
+	return u.Set("owner", v)
+}
+
+// ClearOwner sets the owner field to null.
+func (u *CompanyUpdate) ClearOwner() *CompanyUpdate {
+	return u.Clear("owner")
+}
+
+// SetPhase sets the phase field.
+func (u *CompanyUpdate) SetPhase(v string) *CompanyUpdate {
+	return u.Set("phase", v)
+}
+
+// ClearPhase sets the phase field to null.
+func (u *CompanyUpdate) ClearPhase() *CompanyUpdate {
+	return u.Clear("phase")
+}
+
+// SetPhaseSince sets the phaseSince field.
+func (u *CompanyUpdate) SetPhaseSince(v time.Time) *CompanyUpdate {
+	return u.Set("phaseSince", v)
+}
+
+// ClearPhaseSince sets the phaseSince field to null.
+func (u *CompanyUpdate) ClearPhaseSince() *CompanyUpdate {
+	return u.Clear("phaseSince")
+}
+
+// SetProducts sets the products field.
+func (u *CompanyUpdate) SetProducts(v []string) *CompanyUpdate {
+	return u.Set("products", v)
+}
+
+// ClearProducts sets the products field to null.
+func (u *CompanyUpdate) ClearProducts() *CompanyUpdate {
+	return u.Clear("products")
+}
+
+// SetRenewalDate sets the renewalDate field.
+func (u *CompanyUpdate) SetRenewalDate(v time.Time) *CompanyUpdate {
+	return u.Set("renewalDate", v)
+}
+
+// ClearRenewalDate sets the renewalDate field to null.
+func (u *CompanyUpdate) ClearRenewalDate() *CompanyUpdate {
+	return u.Clear("renewalDate")
+}
+
+// SetRenewalDaysFromNow sets the renewalDaysFromNow field.
+func (u *CompanyUpdate) SetRenewalDaysFromNow(v int) *CompanyUpdate {
+	return u.Set("renewalDaysFromNow", v)
+}
+
+// ClearRenewalDaysFromNow sets the renewalDaysFromNow field to null.
+func (u *CompanyUpdate) ClearRenewalDaysFromNow() *CompanyUpdate {
+	return u.Clear("renewalDaysFromNow")
+}
+
+// SetStatus sets the status field.
+func (u *CompanyUpdate) SetStatus(v string) *CompanyUpdate {
+	return u.Set("status", v)
+}
+
+// ClearStatus sets the status field to null.
+func (u *CompanyUpdate) ClearStatus() *CompanyUpdate {
+	return u.Clear("status")
+}
@@ -4,21 +4,25 @@
 // license that can be found in the LICENSE file.
 
 //go:generate go run gen-accessors.go
+//go:generate go run gen-mocks.go
+//go:generate go run gen-updates.go
 
 package planhat
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"reflect"
 	"time"
 
 	"github.com/google/go-querystring/query"
-	"golang.org/x/time/rate"
 )
 
 // Client is the main planhat client for interacting with the library.  It can be created using NewClient
@@ -38,13 +42,35 @@ type Client struct {
 	//TenantUUID for posting to the metrics endpoint.  Only required if you're sending in metrics.
 	TenantUUID string
 
-	MetricsService *MetricsService
-	AssetService   *AssetService
-	CompanyService *CompanyService
-	EndUserService *EndUserService
-	UserService    *UserService
+	// UserAgent sent with each request.  If empty, the Go HTTP client default is used.
+	UserAgent string
 
-	lim *rate.Limiter
+	// The services are held as interfaces, set by New to the planhat implementations, so that code
+	// taking a *Client can be tested by replacing them with fakes such as those in planhatmock, or
+	// wrapped, e.g. with a CompanyCache.
+	MetricsService MetricsAPI
+	AssetService   AssetAPI
+	CompanyService CompanyAPI
+	EndUserService *EndUserService
+	UserService    UserAPI
+
+	lim        RateLimiter
+	metricsLim RateLimiter
+	retry      retryPolicy
+	logger     *slog.Logger
+	logging    logConfig
+	middleware []Middleware
+	breakers   map[Endpoint]*circuitBreaker
+	roundTrip  RoundTripFunc
+	batch      int
+
+	compress    bool
+	compressMin int
+	dryRun      *DryRun
+
+	skipValidation      bool
+	normaliseDimensions bool
+	dimensions          *DimensionRegistry
 }
 
 // MetricsService represents the Metrics methods
@@ -54,12 +80,14 @@ type MetricsService struct {
 
 // AssetService represents the Assets group
 type AssetService struct {
-	client *Client
+	client   *Client
+	resource *Resource[Asset, AssetListOptions]
 }
 
 // CompanyService represents the Company group
 type CompanyService struct {
-	client *Client
+	client   *Client
+	resource *Resource[Company, CompanyListOptions]
 }
 
 // EndUserService represents the End Users group
@@ -75,38 +103,53 @@ type UserService struct {
 // NewClient is a helper function that returns an new planhat client given a region and an API Key.
 // Optionally you can provide your own http client or use nil to use the default.  This is done to
 // ensure you're aware of the decision you're making to not provide your own http client.
+// It is equivalent to calling New with the WithCluster and WithHTTPClient options.
 func NewClient(apikey string, cluster string, client *http.Client) (*Client, error) {
+	return New(apikey, WithCluster(cluster), WithHTTPClient(client))
+}
+
+// New returns a new planhat client for the given API Key, configured using the provided options.
+// Without any options, the client uses the default `api` cluster, an http client with a 10 second
+// timeout and adaptive rate limits of 150 requests per second for each of the main API and the
+// metrics endpoint.
+func New(apikey string, opts ...Option) (*Client, error) {
 	if apikey == "" {
 		return nil, errors.New("apikey required")
 	}
-	apicluster := ""
-	if cluster == "" {
-		apicluster = "api"
-	} else {
-		apicluster = fmt.Sprintf("api-%s", cluster)
-	}
-	if client == nil {
-		client = &http.Client{
-			Timeout: 10 * time.Second,
-		}
-	}
-	rl := rate.NewLimiter(150, 1)
 	c := &Client{
-		BaseURL:    fmt.Sprintf("https://%s.planhat.com", apicluster),
+		BaseURL:    clusterURL(""),
 		MetricsURL: "https://analytics.planhat.com/dimensiondata",
-		HTTPClient: client,
+		HTTPClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
 		APIKey:     apikey,
-		lim:        rl,
+		lim:        NewAdaptiveLimiter(150, 1),
+		metricsLim: NewAdaptiveLimiter(150, 1),
+		batch:      8,
 	}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+	c.roundTrip = c.chain()
 	c.MetricsService = &MetricsService{client: c}
-	c.AssetService = &AssetService{client: c}
-	c.CompanyService = &CompanyService{client: c}
+	c.AssetService = &AssetService{client: c, resource: NewResource[Asset, AssetListOptions](c, "AssetService", "assets")}
+	c.CompanyService = &CompanyService{client: c, resource: NewResource[Company, CompanyListOptions](c, "CompanyService", "companies")}
 	c.EndUserService = &EndUserService{client: c}
 	c.UserService = &UserService{client: c}
 
 	return c, nil
 }
 
+// clusterURL returns the base URL for the given planhat cluster, e.g. "eu3" becomes https://api-eu3.planhat.com
+func clusterURL(cluster string) string {
+	if cluster == "" {
+		return "https://api.planhat.com"
+	}
+	return fmt.Sprintf("https://api-%s.planhat.com", cluster)
+}
+
 // Bool is a helper routine that allocates a new bool value
 // to store v and returns a pointer to it.
 func Bool(v bool) *bool { return &v }
@@ -127,22 +170,54 @@ func Float64(v float64) *float64 { return &v }
 // to store v and returns a pointer to it.
 func String(v string) *string { return &v }
 
+// newRequest returns a request for the given URL with body, if not nil, encoded as JSON.
+func (c *Client) newRequest(method, url string, body interface{}) (*http.Request, error) {
+	if body == nil {
+		return http.NewRequest(method, url, nil)
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	if c.compress && len(payload) >= c.compressMin {
+		return newGzipRequest(method, url, payload)
+	}
+	return http.NewRequest(method, url, bytes.NewReader(payload))
+}
+
 // makeRequest provides a single function to add common items to the request.
 func (c *Client) makeRequest(ctx context.Context, req *http.Request, v interface{}) error {
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.APIKey))
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-Type", "application/json")
-
-	if !c.lim.Allow() {
-		c.lim.Wait(ctx)
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	if c.compress {
+		req.Header.Set("Accept-Encoding", "gzip")
 	}
 
-	rc := req.WithContext(ctx)
-	res, err := c.HTTPClient.Do(rc)
+	captured := responseFromContext(ctx)
+	if captured != nil {
+		*captured = Response{}
+	}
+	if c.dryRun != nil && req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return c.recordDryRun(ctx, req, v)
+	}
+	ctx = context.WithValue(ctx, redactURLKey{}, c.redactURL)
+	start := time.Now()
+	res, err := c.roundTrip(req.WithContext(ctx))
+	if captured != nil {
+		captured.Duration = time.Since(start)
+	}
 	if err != nil {
 		return err
 	}
 	defer res.Body.Close()
+	if captured != nil {
+		captured.Response = res
+		captured.RateLimit = parseRateLimit(res)
+	}
 
 	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusBadRequest {
 
@@ -167,11 +242,15 @@ func (c *Client) makeRequest(ctx context.Context, req *http.Request, v interface
 
 	}
 
-	if res.StatusCode == http.StatusCreated {
+	if res.StatusCode == http.StatusNoContent {
 		return nil
 	}
 
-	if err = json.NewDecoder(res.Body).Decode(&v); err != nil {
+	if s, ok := v.(streamDecoder); ok {
+		return s.decodeStream(res.Body)
+	}
+
+	if err = json.NewDecoder(res.Body).Decode(&v); err != nil && err != io.EOF {
 		return err
 	}
 
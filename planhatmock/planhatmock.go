@@ -0,0 +1,568 @@
+// Copyright 2021 The go-planhat AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+// Code generated by gen-mocks; DO NOT EDIT.
+
+// Package planhatmock provides mock implementations of the planhat service interfaces for use in
+// tests.  Each mock records the calls made to it and delegates to an optional function field for
+// each method, returning zero values if the function is not set:
+//
+//	companies := &planhatmock.CompanyAPI{
+//		GetFunc: func(ctx context.Context, id string) (*planhat.Company, error) {
+//			return &planhat.Company{ID: planhat.String(id)}, nil
+//		},
+//	}
+//	company, _ := companies.Get(ctx, "123")
+//	log.Println(len(companies.CallsTo("Get")))
+package planhatmock
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/darrenparkinson/planhat"
+)
+
+// Call records a single call to a mock method.
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// recorder records calls to a mock.
+type recorder struct {
+	mu    sync.Mutex
+	calls []Call
+}
+
+func (r *recorder) record(method string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, Call{Method: method, Args: args})
+}
+
+// Calls returns all calls made to the mock in order.
+func (r *recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// CallsTo returns the calls made to the named method in order.
+func (r *recorder) CallsTo(method string) []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var calls []Call
+	for _, c := range r.calls {
+		if c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// Reset clears the recorded calls.
+func (r *recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
+
+// AssetAPI is a mock implementation of planhat.AssetAPI.
+type AssetAPI struct {
+	recorder
+
+	// CreateFunc is called by Create if set.
+	CreateFunc func(ctx context.Context, asset planhat.Asset) (*planhat.Asset, error)
+	// UpdateFunc is called by Update if set.
+	UpdateFunc func(ctx context.Context, id string, asset planhat.Asset) (*planhat.Asset, error)
+	// UpdateByKeyFunc is called by UpdateByKey if set.
+	UpdateByKeyFunc func(ctx context.Context, key planhat.Key, asset planhat.Asset) (*planhat.Asset, error)
+	// UpdateFieldsFunc is called by UpdateFields if set.
+	UpdateFieldsFunc func(ctx context.Context, key planhat.Key, update *planhat.AssetUpdate) (*planhat.Asset, error)
+	// UpdateIfChangedFunc is called by UpdateIfChanged if set.
+	UpdateIfChangedFunc func(ctx context.Context, key planhat.Key, asset planhat.Asset) (*planhat.Asset, []planhat.Change, error)
+	// GetFunc is called by Get if set.
+	GetFunc func(ctx context.Context, id string) (*planhat.Asset, error)
+	// GetByKeyFunc is called by GetByKey if set.
+	GetByKeyFunc func(ctx context.Context, key planhat.Key) (*planhat.Asset, error)
+	// GetByExternalIDFunc is called by GetByExternalID if set.
+	GetByExternalIDFunc func(ctx context.Context, externalID string) (*planhat.Asset, error)
+	// GetBySourceIDFunc is called by GetBySourceID if set.
+	GetBySourceIDFunc func(ctx context.Context, sourceID string) (*planhat.Asset, error)
+	// GetManyFunc is called by GetMany if set.
+	GetManyFunc func(ctx context.Context, keys []planhat.Key) []planhat.Result[planhat.Asset]
+	// ListFunc is called by List if set.
+	ListFunc func(ctx context.Context, options ...*planhat.AssetListOptions) ([]*planhat.Asset, error)
+	// ListEachFunc is called by ListEach if set.
+	ListEachFunc func(ctx context.Context, fn func(*planhat.Asset) error, options ...*planhat.AssetListOptions) error
+	// DeleteFunc is called by Delete if set.
+	DeleteFunc func(ctx context.Context, id string) (*planhat.DeleteResponse, error)
+	// DeleteByKeyFunc is called by DeleteByKey if set.
+	DeleteByKeyFunc func(ctx context.Context, key planhat.Key) (*planhat.DeleteResponse, error)
+	// BulkUpsertFunc is called by BulkUpsert if set.
+	BulkUpsertFunc func(ctx context.Context, assets []planhat.Asset) (*planhat.UpsertResponse, error)
+}
+
+var _ planhat.AssetAPI = (*AssetAPI)(nil)
+
+// Create records the call and calls CreateFunc if set.
+func (m *AssetAPI) Create(ctx context.Context, asset planhat.Asset) (*planhat.Asset, error) {
+	m.record("Create", ctx, asset)
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, asset)
+	}
+	var r0 *planhat.Asset
+	var r1 error
+	return r0, r1
+}
+
+// Update records the call and calls UpdateFunc if set.
+func (m *AssetAPI) Update(ctx context.Context, id string, asset planhat.Asset) (*planhat.Asset, error) {
+	m.record("Update", ctx, id, asset)
+	if m.UpdateFunc != nil {
+		return m.UpdateFunc(ctx, id, asset)
+	}
+	var r0 *planhat.Asset
+	var r1 error
+	return r0, r1
+}
+
+// UpdateByKey records the call and calls UpdateByKeyFunc if set.
+func (m *AssetAPI) UpdateByKey(ctx context.Context, key planhat.Key, asset planhat.Asset) (*planhat.Asset, error) {
+	m.record("UpdateByKey", ctx, key, asset)
+	if m.UpdateByKeyFunc != nil {
+		return m.UpdateByKeyFunc(ctx, key, asset)
+	}
+	var r0 *planhat.Asset
+	var r1 error
+	return r0, r1
+}
+
+// UpdateFields records the call and calls UpdateFieldsFunc if set.
+func (m *AssetAPI) UpdateFields(ctx context.Context, key planhat.Key, update *planhat.AssetUpdate) (*planhat.Asset, error) {
+	m.record("UpdateFields", ctx, key, update)
+	if m.UpdateFieldsFunc != nil {
+		return m.UpdateFieldsFunc(ctx, key, update)
+	}
+	var r0 *planhat.Asset
+	var r1 error
+	return r0, r1
+}
+
+// UpdateIfChanged records the call and calls UpdateIfChangedFunc if set.
+func (m *AssetAPI) UpdateIfChanged(ctx context.Context, key planhat.Key, asset planhat.Asset) (*planhat.Asset, []planhat.Change, error) {
+	m.record("UpdateIfChanged", ctx, key, asset)
+	if m.UpdateIfChangedFunc != nil {
+		return m.UpdateIfChangedFunc(ctx, key, asset)
+	}
+	var r0 *planhat.Asset
+	var r1 []planhat.Change
+	var r2 error
+	return r0, r1, r2
+}
+
+// Get records the call and calls GetFunc if set.
+func (m *AssetAPI) Get(ctx context.Context, id string) (*planhat.Asset, error) {
+	m.record("Get", ctx, id)
+	if m.GetFunc != nil {
+		return m.GetFunc(ctx, id)
+	}
+	var r0 *planhat.Asset
+	var r1 error
+	return r0, r1
+}
+
+// GetByKey records the call and calls GetByKeyFunc if set.
+func (m *AssetAPI) GetByKey(ctx context.Context, key planhat.Key) (*planhat.Asset, error) {
+	m.record("GetByKey", ctx, key)
+	if m.GetByKeyFunc != nil {
+		return m.GetByKeyFunc(ctx, key)
+	}
+	var r0 *planhat.Asset
+	var r1 error
+	return r0, r1
+}
+
+// GetByExternalID records the call and calls GetByExternalIDFunc if set.
+func (m *AssetAPI) GetByExternalID(ctx context.Context, externalID string) (*planhat.Asset, error) {
+	m.record("GetByExternalID", ctx, externalID)
+	if m.GetByExternalIDFunc != nil {
+		return m.GetByExternalIDFunc(ctx, externalID)
+	}
+	var r0 *planhat.Asset
+	var r1 error
+	return r0, r1
+}
+
+// GetBySourceID records the call and calls GetBySourceIDFunc if set.
+func (m *AssetAPI) GetBySourceID(ctx context.Context, sourceID string) (*planhat.Asset, error) {
+	m.record("GetBySourceID", ctx, sourceID)
+	if m.GetBySourceIDFunc != nil {
+		return m.GetBySourceIDFunc(ctx, sourceID)
+	}
+	var r0 *planhat.Asset
+	var r1 error
+	return r0, r1
+}
+
+// GetMany records the call and calls GetManyFunc if set.
+func (m *AssetAPI) GetMany(ctx context.Context, keys []planhat.Key) []planhat.Result[planhat.Asset] {
+	m.record("GetMany", ctx, keys)
+	if m.GetManyFunc != nil {
+		return m.GetManyFunc(ctx, keys)
+	}
+	var r0 []planhat.Result[planhat.Asset]
+	return r0
+}
+
+// List records the call and calls ListFunc if set.
+func (m *AssetAPI) List(ctx context.Context, options ...*planhat.AssetListOptions) ([]*planhat.Asset, error) {
+	m.record("List", ctx, options)
+	if m.ListFunc != nil {
+		return m.ListFunc(ctx, options...)
+	}
+	var r0 []*planhat.Asset
+	var r1 error
+	return r0, r1
+}
+
+// ListEach records the call and calls ListEachFunc if set.
+func (m *AssetAPI) ListEach(ctx context.Context, fn func(*planhat.Asset) error, options ...*planhat.AssetListOptions) error {
+	m.record("ListEach", ctx, fn, options)
+	if m.ListEachFunc != nil {
+		return m.ListEachFunc(ctx, fn, options...)
+	}
+	var r0 error
+	return r0
+}
+
+// Delete records the call and calls DeleteFunc if set.
+func (m *AssetAPI) Delete(ctx context.Context, id string) (*planhat.DeleteResponse, error) {
+	m.record("Delete", ctx, id)
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(ctx, id)
+	}
+	var r0 *planhat.DeleteResponse
+	var r1 error
+	return r0, r1
+}
+
+// DeleteByKey records the call and calls DeleteByKeyFunc if set.
+func (m *AssetAPI) DeleteByKey(ctx context.Context, key planhat.Key) (*planhat.DeleteResponse, error) {
+	m.record("DeleteByKey", ctx, key)
+	if m.DeleteByKeyFunc != nil {
+		return m.DeleteByKeyFunc(ctx, key)
+	}
+	var r0 *planhat.DeleteResponse
+	var r1 error
+	return r0, r1
+}
+
+// BulkUpsert records the call and calls BulkUpsertFunc if set.
+func (m *AssetAPI) BulkUpsert(ctx context.Context, assets []planhat.Asset) (*planhat.UpsertResponse, error) {
+	m.record("BulkUpsert", ctx, assets)
+	if m.BulkUpsertFunc != nil {
+		return m.BulkUpsertFunc(ctx, assets)
+	}
+	var r0 *planhat.UpsertResponse
+	var r1 error
+	return r0, r1
+}
+
+// CompanyAPI is a mock implementation of planhat.CompanyAPI.
+type CompanyAPI struct {
+	recorder
+
+	// CreateFunc is called by Create if set.
+	CreateFunc func(ctx context.Context, company planhat.Company) (*planhat.Company, error)
+	// UpdateFunc is called by Update if set.
+	UpdateFunc func(ctx context.Context, id string, company planhat.Company) (*planhat.Company, error)
+	// UpdateByKeyFunc is called by UpdateByKey if set.
+	UpdateByKeyFunc func(ctx context.Context, key planhat.Key, company planhat.Company) (*planhat.Company, error)
+	// UpdateFieldsFunc is called by UpdateFields if set.
+	UpdateFieldsFunc func(ctx context.Context, key planhat.Key, update *planhat.CompanyUpdate) (*planhat.Company, error)
+	// UpdateIfChangedFunc is called by UpdateIfChanged if set.
+	UpdateIfChangedFunc func(ctx context.Context, key planhat.Key, company planhat.Company) (*planhat.Company, []planhat.Change, error)
+	// GetFunc is called by Get if set.
+	GetFunc func(ctx context.Context, id string) (*planhat.Company, error)
+	// GetByKeyFunc is called by GetByKey if set.
+	GetByKeyFunc func(ctx context.Context, key planhat.Key) (*planhat.Company, error)
+	// GetByExternalIDFunc is called by GetByExternalID if set.
+	GetByExternalIDFunc func(ctx context.Context, externalID string) (*planhat.Company, error)
+	// GetBySourceIDFunc is called by GetBySourceID if set.
+	GetBySourceIDFunc func(ctx context.Context, sourceID string) (*planhat.Company, error)
+	// GetManyFunc is called by GetMany if set.
+	GetManyFunc func(ctx context.Context, keys []planhat.Key) []planhat.Result[planhat.Company]
+	// ListFunc is called by List if set.
+	ListFunc func(ctx context.Context, options ...*planhat.CompanyListOptions) ([]*planhat.Company, error)
+	// ListEachFunc is called by ListEach if set.
+	ListEachFunc func(ctx context.Context, fn func(*planhat.Company) error, options ...*planhat.CompanyListOptions) error
+	// LeanListFunc is called by LeanList if set.
+	LeanListFunc func(ctx context.Context, options ...*planhat.LeanCompanyListOptions) ([]*planhat.LeanCompany, error)
+	// LeanListEachFunc is called by LeanListEach if set.
+	LeanListEachFunc func(ctx context.Context, fn func(*planhat.LeanCompany) error, options ...*planhat.LeanCompanyListOptions) error
+	// DeleteFunc is called by Delete if set.
+	DeleteFunc func(ctx context.Context, id string) (*planhat.DeleteResponse, error)
+	// DeleteByKeyFunc is called by DeleteByKey if set.
+	DeleteByKeyFunc func(ctx context.Context, key planhat.Key) (*planhat.DeleteResponse, error)
+	// BulkUpsertFunc is called by BulkUpsert if set.
+	BulkUpsertFunc func(ctx context.Context, companies []planhat.Company) (*planhat.UpsertResponse, error)
+}
+
+var _ planhat.CompanyAPI = (*CompanyAPI)(nil)
+
+// Create records the call and calls CreateFunc if set.
+func (m *CompanyAPI) Create(ctx context.Context, company planhat.Company) (*planhat.Company, error) {
+	m.record("Create", ctx, company)
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, company)
+	}
+	var r0 *planhat.Company
+	var r1 error
+	return r0, r1
+}
+
+// Update records the call and calls UpdateFunc if set.
+func (m *CompanyAPI) Update(ctx context.Context, id string, company planhat.Company) (*planhat.Company, error) {
+	m.record("Update", ctx, id, company)
+	if m.UpdateFunc != nil {
+		return m.UpdateFunc(ctx, id, company)
+	}
+	var r0 *planhat.Company
+	var r1 error
+	return r0, r1
+}
+
+// UpdateByKey records the call and calls UpdateByKeyFunc if set.
+func (m *CompanyAPI) UpdateByKey(ctx context.Context, key planhat.Key, company planhat.Company) (*planhat.Company, error) {
+	m.record("UpdateByKey", ctx, key, company)
+	if m.UpdateByKeyFunc != nil {
+		return m.UpdateByKeyFunc(ctx, key, company)
+	}
+	var r0 *planhat.Company
+	var r1 error
+	return r0, r1
+}
+
+// UpdateFields records the call and calls UpdateFieldsFunc if set.
+func (m *CompanyAPI) UpdateFields(ctx context.Context, key planhat.Key, update *planhat.CompanyUpdate) (*planhat.Company, error) {
+	m.record("UpdateFields", ctx, key, update)
+	if m.UpdateFieldsFunc != nil {
+		return m.UpdateFieldsFunc(ctx, key, update)
+	}
+	var r0 *planhat.Company
+	var r1 error
+	return r0, r1
+}
+
+// UpdateIfChanged records the call and calls UpdateIfChangedFunc if set.
+func (m *CompanyAPI) UpdateIfChanged(ctx context.Context, key planhat.Key, company planhat.Company) (*planhat.Company, []planhat.Change, error) {
+	m.record("UpdateIfChanged", ctx, key, company)
+	if m.UpdateIfChangedFunc != nil {
+		return m.UpdateIfChangedFunc(ctx, key, company)
+	}
+	var r0 *planhat.Company
+	var r1 []planhat.Change
+	var r2 error
+	return r0, r1, r2
+}
+
+// Get records the call and calls GetFunc if set.
+func (m *CompanyAPI) Get(ctx context.Context, id string) (*planhat.Company, error) {
+	m.record("Get", ctx, id)
+	if m.GetFunc != nil {
+		return m.GetFunc(ctx, id)
+	}
+	var r0 *planhat.Company
+	var r1 error
+	return r0, r1
+}
+
+// GetByKey records the call and calls GetByKeyFunc if set.
+func (m *CompanyAPI) GetByKey(ctx context.Context, key planhat.Key) (*planhat.Company, error) {
+	m.record("GetByKey", ctx, key)
+	if m.GetByKeyFunc != nil {
+		return m.GetByKeyFunc(ctx, key)
+	}
+	var r0 *planhat.Company
+	var r1 error
+	return r0, r1
+}
+
+// GetByExternalID records the call and calls GetByExternalIDFunc if set.
+func (m *CompanyAPI) GetByExternalID(ctx context.Context, externalID string) (*planhat.Company, error) {
+	m.record("GetByExternalID", ctx, externalID)
+	if m.GetByExternalIDFunc != nil {
+		return m.GetByExternalIDFunc(ctx, externalID)
+	}
+	var r0 *planhat.Company
+	var r1 error
+	return r0, r1
+}
+
+// GetBySourceID records the call and calls GetBySourceIDFunc if set.
+func (m *CompanyAPI) GetBySourceID(ctx context.Context, sourceID string) (*planhat.Company, error) {
+	m.record("GetBySourceID", ctx, sourceID)
+	if m.GetBySourceIDFunc != nil {
+		return m.GetBySourceIDFunc(ctx, sourceID)
+	}
+	var r0 *planhat.Company
+	var r1 error
+	return r0, r1
+}
+
+// GetMany records the call and calls GetManyFunc if set.
+func (m *CompanyAPI) GetMany(ctx context.Context, keys []planhat.Key) []planhat.Result[planhat.Company] {
+	m.record("GetMany", ctx, keys)
+	if m.GetManyFunc != nil {
+		return m.GetManyFunc(ctx, keys)
+	}
+	var r0 []planhat.Result[planhat.Company]
+	return r0
+}
+
+// List records the call and calls ListFunc if set.
+func (m *CompanyAPI) List(ctx context.Context, options ...*planhat.CompanyListOptions) ([]*planhat.Company, error) {
+	m.record("List", ctx, options)
+	if m.ListFunc != nil {
+		return m.ListFunc(ctx, options...)
+	}
+	var r0 []*planhat.Company
+	var r1 error
+	return r0, r1
+}
+
+// ListEach records the call and calls ListEachFunc if set.
+func (m *CompanyAPI) ListEach(ctx context.Context, fn func(*planhat.Company) error, options ...*planhat.CompanyListOptions) error {
+	m.record("ListEach", ctx, fn, options)
+	if m.ListEachFunc != nil {
+		return m.ListEachFunc(ctx, fn, options...)
+	}
+	var r0 error
+	return r0
+}
+
+// LeanList records the call and calls LeanListFunc if set.
+func (m *CompanyAPI) LeanList(ctx context.Context, options ...*planhat.LeanCompanyListOptions) ([]*planhat.LeanCompany, error) {
+	m.record("LeanList", ctx, options)
+	if m.LeanListFunc != nil {
+		return m.LeanListFunc(ctx, options...)
+	}
+	var r0 []*planhat.LeanCompany
+	var r1 error
+	return r0, r1
+}
+
+// LeanListEach records the call and calls LeanListEachFunc if set.
+func (m *CompanyAPI) LeanListEach(ctx context.Context, fn func(*planhat.LeanCompany) error, options ...*planhat.LeanCompanyListOptions) error {
+	m.record("LeanListEach", ctx, fn, options)
+	if m.LeanListEachFunc != nil {
+		return m.LeanListEachFunc(ctx, fn, options...)
+	}
+	var r0 error
+	return r0
+}
+
+// Delete records the call and calls DeleteFunc if set.
+func (m *CompanyAPI) Delete(ctx context.Context, id string) (*planhat.DeleteResponse, error) {
+	m.record("Delete", ctx, id)
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(ctx, id)
+	}
+	var r0 *planhat.DeleteResponse
+	var r1 error
+	return r0, r1
+}
+
+// DeleteByKey records the call and calls DeleteByKeyFunc if set.
+func (m *CompanyAPI) DeleteByKey(ctx context.Context, key planhat.Key) (*planhat.DeleteResponse, error) {
+	m.record("DeleteByKey", ctx, key)
+	if m.DeleteByKeyFunc != nil {
+		return m.DeleteByKeyFunc(ctx, key)
+	}
+	var r0 *planhat.DeleteResponse
+	var r1 error
+	return r0, r1
+}
+
+// BulkUpsert records the call and calls BulkUpsertFunc if set.
+func (m *CompanyAPI) BulkUpsert(ctx context.Context, companies []planhat.Company) (*planhat.UpsertResponse, error) {
+	m.record("BulkUpsert", ctx, companies)
+	if m.BulkUpsertFunc != nil {
+		return m.BulkUpsertFunc(ctx, companies)
+	}
+	var r0 *planhat.UpsertResponse
+	var r1 error
+	return r0, r1
+}
+
+// MetricsAPI is a mock implementation of planhat.MetricsAPI.
+type MetricsAPI struct {
+	recorder
+
+	// ListFunc is called by List if set.
+	ListFunc func(ctx context.Context, options ...*planhat.MetricsListOptions) ([]*planhat.DimensionData, error)
+	// ListRangeFunc is called by ListRange if set.
+	ListRangeFunc func(ctx context.Context, cid string, dimID string, from time.Time, to time.Time) ([]*planhat.DimensionData, error)
+	// BulkUpsertFunc is called by BulkUpsert if set.
+	BulkUpsertFunc func(ctx context.Context, metrics []planhat.Metric) (*planhat.UpsertMetricsResponse, error)
+}
+
+var _ planhat.MetricsAPI = (*MetricsAPI)(nil)
+
+// List records the call and calls ListFunc if set.
+func (m *MetricsAPI) List(ctx context.Context, options ...*planhat.MetricsListOptions) ([]*planhat.DimensionData, error) {
+	m.record("List", ctx, options)
+	if m.ListFunc != nil {
+		return m.ListFunc(ctx, options...)
+	}
+	var r0 []*planhat.DimensionData
+	var r1 error
+	return r0, r1
+}
+
+// ListRange records the call and calls ListRangeFunc if set.
+func (m *MetricsAPI) ListRange(ctx context.Context, cid string, dimID string, from time.Time, to time.Time) ([]*planhat.DimensionData, error) {
+	m.record("ListRange", ctx, cid, dimID, from, to)
+	if m.ListRangeFunc != nil {
+		return m.ListRangeFunc(ctx, cid, dimID, from, to)
+	}
+	var r0 []*planhat.DimensionData
+	var r1 error
+	return r0, r1
+}
+
+// BulkUpsert records the call and calls BulkUpsertFunc if set.
+func (m *MetricsAPI) BulkUpsert(ctx context.Context, metrics []planhat.Metric) (*planhat.UpsertMetricsResponse, error) {
+	m.record("BulkUpsert", ctx, metrics)
+	if m.BulkUpsertFunc != nil {
+		return m.BulkUpsertFunc(ctx, metrics)
+	}
+	var r0 *planhat.UpsertMetricsResponse
+	var r1 error
+	return r0, r1
+}
+
+// UserAPI is a mock implementation of planhat.UserAPI.
+type UserAPI struct {
+	recorder
+
+	// ListFunc is called by List if set.
+	ListFunc func(ctx context.Context) ([]*planhat.User, error)
+}
+
+var _ planhat.UserAPI = (*UserAPI)(nil)
+
+// List records the call and calls ListFunc if set.
+func (m *UserAPI) List(ctx context.Context) ([]*planhat.User, error) {
+	m.record("List", ctx)
+	if m.ListFunc != nil {
+		return m.ListFunc(ctx)
+	}
+	var r0 []*planhat.User
+	var r1 error
+	return r0, r1
+}
@@ -0,0 +1,68 @@
+package planhatmock
+
+import (
+	"context"
+	"testing"
+
+	"github.com/darrenparkinson/planhat"
+)
+
+// companyName is an example of code under test that depends on planhat.CompanyAPI.
+func companyName(ctx context.Context, api planhat.CompanyAPI, id string) string {
+	co, err := api.Get(ctx, id)
+	if err != nil {
+		return ""
+	}
+	return co.GetName()
+}
+
+func TestCompanyAPI(t *testing.T) {
+	m := &CompanyAPI{
+		GetFunc: func(ctx context.Context, id string) (*planhat.Company, error) {
+			return &planhat.Company{ID: planhat.String(id), Name: planhat.String("Acme")}, nil
+		},
+	}
+	ctx := context.Background()
+	if got := companyName(ctx, m, "123"); got != "Acme" {
+		t.Errorf("got: %v; want Acme", got)
+	}
+	m.List(ctx, &planhat.CompanyListOptions{Limit: planhat.Int(1)})
+	if co, err := m.Create(ctx, planhat.Company{}); co != nil || err != nil {
+		t.Errorf("unset functions should return zero values, got %v, %v", co, err)
+	}
+
+	calls := m.CallsTo("Get")
+	if len(calls) != 1 || calls[0].Args[1] != "123" {
+		t.Errorf("unexpected calls to Get: %+v", calls)
+	}
+	if got := len(m.Calls()); got != 3 {
+		t.Errorf("got %d calls; want 3", got)
+	}
+	m.Reset()
+	if got := len(m.Calls()); got != 0 {
+		t.Errorf("got %d calls after reset; want 0", got)
+	}
+}
+
+// pushLogins is an example of code under test that takes a *planhat.Client.
+func pushLogins(ctx context.Context, ph *planhat.Client, externalID string, n float64) error {
+	_, err := ph.MetricsService.BulkUpsert(ctx, []planhat.Metric{{
+		DimensionID: planhat.String("logins"),
+		Value:       planhat.Float64(n),
+		ExternalID:  planhat.String(externalID),
+	}})
+	return err
+}
+
+func TestClientWithMocks(t *testing.T) {
+	ph, _ := planhat.New("key")
+	m := &MetricsAPI{}
+	ph.MetricsService = m
+	if err := pushLogins(context.Background(), ph, "acme", 3); err != nil {
+		t.Fatal(err)
+	}
+	calls := m.CallsTo("BulkUpsert")
+	if len(calls) != 1 || *calls[0].Args[1].([]planhat.Metric)[0].Value != 3 {
+		t.Errorf("unexpected calls to BulkUpsert: %+v", calls)
+	}
+}
@@ -0,0 +1,218 @@
+package planhat
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AmbiguousError is returned by a CompanyResolver when an identifier or name matches more than one
+// company.  It wraps ErrAmbiguous.
+type AmbiguousError struct {
+	// Field is the field that was matched, e.g. "externalId" or "name".
+	Field string
+	// Value is the value that was looked up.
+	Value string
+	// Matches are the companies matching the value.
+	Matches []LeanCompany
+}
+
+func (e *AmbiguousError) Error() string {
+	return fmt.Sprintf("%v: %s %q matches %d companies", ErrAmbiguous, e.Field, e.Value, len(e.Matches))
+}
+
+func (e *AmbiguousError) Unwrap() error { return ErrAmbiguous }
+
+// Duplicate reports a value shared by more than one company.
+type Duplicate struct {
+	// Field is the field holding the value: "externalId", "sourceId" or "name".
+	Field string
+	// Value is the shared value.
+	Value string
+	// Companies are the companies sharing the value.
+	Companies []LeanCompany
+}
+
+// CompanyResolver maps between the _id, externalId, sourceId, name and slug of every company, as
+// returned by LeanList, without a request per lookup.  Call Load before use and, for long running
+// processes, AutoRefresh to keep the mapping up to date:
+//
+//	resolver := planhat.NewCompanyResolver(ph.CompanyService)
+//	if err := resolver.Load(ctx); err != nil { ... }
+//	go resolver.AutoRefresh(ctx, 15*time.Minute, func(err error) { log.Println(err) })
+//	id, err := resolver.ResolveID(planhat.ByExternalID("acme"))
+//
+// Names are matched case-insensitively, ignoring surrounding space.  Lookups of a value held by more
+// than one company return an *AmbiguousError, and Duplicates lists every such value.
+type CompanyResolver struct {
+	api     CompanyAPI
+	options []*LeanCompanyListOptions
+
+	mu       sync.RWMutex
+	index    map[string]map[string][]*LeanCompany // field -> value -> companies
+	count    int
+	loadedAt time.Time
+}
+
+// resolver fields, as reported in AmbiguousError and Duplicate.
+const (
+	fieldID         = "_id"
+	fieldExternalID = "externalId"
+	fieldSourceID   = "sourceId"
+	fieldName       = "name"
+	fieldSlug       = "slug"
+)
+
+// NewCompanyResolver returns a CompanyResolver loading companies from api using the options
+// provided, e.g. to restrict it to a status.
+func NewCompanyResolver(api CompanyAPI, options ...*LeanCompanyListOptions) *CompanyResolver {
+	return &CompanyResolver{api: api, options: options}
+}
+
+// Load fetches the companies and replaces the mapping.  The previous mapping is kept if it fails.
+func (r *CompanyResolver) Load(ctx context.Context) error {
+	companies, err := r.api.LeanList(ctx, r.options...)
+	if err != nil {
+		return err
+	}
+	index := map[string]map[string][]*LeanCompany{
+		fieldID:         {},
+		fieldExternalID: {},
+		fieldSourceID:   {},
+		fieldName:       {},
+		fieldSlug:       {},
+	}
+	add := func(field, value string, co *LeanCompany) {
+		if value != "" {
+			index[field][value] = append(index[field][value], co)
+		}
+	}
+	for _, co := range companies {
+		if co == nil {
+			continue
+		}
+		add(fieldID, co.ID, co)
+		add(fieldExternalID, co.ExternalID, co)
+		add(fieldSourceID, co.SourceID, co)
+		add(fieldName, normaliseName(co.Name), co)
+		add(fieldSlug, co.Slug, co)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.index = index
+	r.count = len(companies)
+	r.loadedAt = time.Now()
+	return nil
+}
+
+// AutoRefresh calls Load every interval until ctx is done, passing any errors to onError if it is not
+// nil.  It blocks, so is usually run in its own goroutine.
+func (r *CompanyResolver) AutoRefresh(ctx context.Context, interval time.Duration, onError func(error)) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := r.Load(ctx); err != nil && onError != nil && ctx.Err() == nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// LoadedAt returns when the mapping was last loaded, or the zero time if it hasn't been.
+func (r *CompanyResolver) LoadedAt() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.loadedAt
+}
+
+// Len returns the number of companies loaded.
+func (r *CompanyResolver) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.count
+}
+
+// Resolve returns the company identified by key.  It returns ErrNotFound if there is no such company
+// and an *AmbiguousError if there are several.
+func (r *CompanyResolver) Resolve(key Key) (*LeanCompany, error) {
+	switch {
+	case key.IsExternalID():
+		return r.lookup(fieldExternalID, key.Value())
+	case key.IsSourceID():
+		return r.lookup(fieldSourceID, key.Value())
+	}
+	return r.lookup(fieldID, key.Value())
+}
+
+// ResolveID returns the planhat _id of the company identified by key.
+func (r *CompanyResolver) ResolveID(key Key) (string, error) {
+	co, err := r.Resolve(key)
+	if err != nil {
+		return "", err
+	}
+	return co.ID, nil
+}
+
+// ResolveName returns the company with the given name, compared case-insensitively.
+func (r *CompanyResolver) ResolveName(name string) (*LeanCompany, error) {
+	return r.lookup(fieldName, normaliseName(name))
+}
+
+// ResolveSlug returns the company with the given slug.
+func (r *CompanyResolver) ResolveSlug(slug string) (*LeanCompany, error) {
+	return r.lookup(fieldSlug, slug)
+}
+
+func (r *CompanyResolver) lookup(field, value string) (*LeanCompany, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	matches := r.index[field][value]
+	switch len(matches) {
+	case 0:
+		return nil, ErrNotFound
+	case 1:
+		co := *matches[0]
+		return &co, nil
+	}
+	return nil, &AmbiguousError{Field: field, Value: value, Matches: copyLean(matches)}
+}
+
+// Duplicates returns the externalIds, sourceIds and names shared by more than one company, sorted by
+// field and value.
+func (r *CompanyResolver) Duplicates() []Duplicate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var dups []Duplicate
+	for _, field := range []string{fieldExternalID, fieldSourceID, fieldName} {
+		var values []string
+		for v, cos := range r.index[field] {
+			if len(cos) > 1 {
+				values = append(values, v)
+			}
+		}
+		sort.Strings(values)
+		for _, v := range values {
+			dups = append(dups, Duplicate{Field: field, Value: v, Companies: copyLean(r.index[field][v])})
+		}
+	}
+	return dups
+}
+
+func normaliseName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func copyLean(cos []*LeanCompany) []LeanCompany {
+	out := make([]LeanCompany, len(cos))
+	for i, co := range cos {
+		out[i] = *co
+	}
+	return out
+}
@@ -0,0 +1,78 @@
+package planhat
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type fakeLeanList struct {
+	CompanyAPI
+	companies []*LeanCompany
+	err       error
+}
+
+func (f *fakeLeanList) LeanList(ctx context.Context, options ...*LeanCompanyListOptions) ([]*LeanCompany, error) {
+	return f.companies, f.err
+}
+
+func TestCompanyResolver(t *testing.T) {
+	f := &fakeLeanList{companies: []*LeanCompany{
+		{ID: "1", Name: "Acme", ExternalID: "a", SourceID: "s1", Slug: "acme"},
+		{ID: "2", Name: "acme ", ExternalID: "b", Slug: "acme-2"},
+		{ID: "3", Name: "Beta", ExternalID: "b", SourceID: "s3", Slug: "beta"},
+	}}
+	r := NewCompanyResolver(f)
+	if err := r.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if r.Len() != 3 || r.LoadedAt().IsZero() {
+		t.Errorf("Len = %d, LoadedAt = %v", r.Len(), r.LoadedAt())
+	}
+
+	tests := []struct {
+		name    string
+		resolve func() (*LeanCompany, error)
+		wantID  string
+		wantErr error
+	}{
+		{"id", func() (*LeanCompany, error) { return r.Resolve(ByID("3")) }, "3", nil},
+		{"externalId", func() (*LeanCompany, error) { return r.Resolve(ByExternalID("a")) }, "1", nil},
+		{"sourceId", func() (*LeanCompany, error) { return r.Resolve(BySourceID("s3")) }, "3", nil},
+		{"name", func() (*LeanCompany, error) { return r.ResolveName("BETA") }, "3", nil},
+		{"slug", func() (*LeanCompany, error) { return r.ResolveSlug("acme-2") }, "2", nil},
+		{"missing", func() (*LeanCompany, error) { return r.Resolve(ByExternalID("z")) }, "", ErrNotFound},
+		{"duplicate externalId", func() (*LeanCompany, error) { return r.Resolve(ByExternalID("b")) }, "", ErrAmbiguous},
+		{"ambiguous name", func() (*LeanCompany, error) { return r.ResolveName("ACME") }, "", ErrAmbiguous},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			co, err := tt.resolve()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && co.ID != tt.wantID {
+				t.Errorf("ID = %q, want %q", co.ID, tt.wantID)
+			}
+		})
+	}
+
+	var ae *AmbiguousError
+	if _, err := r.ResolveName("acme"); !errors.As(err, &ae) || len(ae.Matches) != 2 {
+		t.Errorf("err = %v", err)
+	}
+
+	dups := r.Duplicates()
+	if len(dups) != 2 || dups[0].Field != "externalId" || dups[0].Value != "b" || dups[1].Field != "name" || dups[1].Value != "acme" {
+		t.Errorf("Duplicates = %+v", dups)
+	}
+
+	// A failed reload keeps the previous mapping.
+	f.err = ErrInternalError
+	if err := r.Load(context.Background()); err != ErrInternalError {
+		t.Fatalf("Load err = %v", err)
+	}
+	if id, err := r.ResolveID(ByExternalID("a")); err != nil || id != "1" {
+		t.Errorf("ResolveID = %q, %v", id, err)
+	}
+}
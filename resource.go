@@ -0,0 +1,304 @@
+package planhat
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Resource implements the standard planhat operations for a model, such as Company or Asset, once
+// given the path of its endpoint.  T is the model type and O the type of its list options.
+//
+// The services on the Client are built on Resource.  It can also be used directly for models that
+// don't yet have a service, provided T and O are defined:
+//
+//	projects := planhat.NewResource[Project, ProjectListOptions](ph, "ProjectService", "projects")
+//	project, err := projects.Get(ctx, id)
+type Resource[T any, O any] struct {
+	client *Client
+	name   string
+	path   string
+}
+
+// NewResource returns a Resource for the model at the given path, e.g. "companies".  The name is
+// used to identify operations for middleware, e.g. "CompanyService" gives "CompanyService.Get".
+func NewResource[T any, O any](client *Client, name, path string) *Resource[T, O] {
+	return &Resource[T, O]{client: client, name: name, path: path}
+}
+
+// url returns the URL of the resource, with an optional id appended.
+func (r *Resource[T, O]) url(id ...string) string {
+	if len(id) > 0 {
+		return fmt.Sprintf("%s/%s/%s", r.client.BaseURL, r.path, id[0])
+	}
+	return fmt.Sprintf("%s/%s", r.client.BaseURL, r.path)
+}
+
+// op returns a context recording the named operation on the resource.
+func (r *Resource[T, O]) op(ctx context.Context, method string) context.Context {
+	return withOperation(ctx, r.name+"."+method)
+}
+
+// Create creates a new record, after validating it if T has a Validate method.
+func (r *Resource[T, O]) Create(ctx context.Context, item T) (*T, error) {
+	ctx = r.op(ctx, "Create")
+	created := new(T)
+	if err := r.client.validateOne(&item); err != nil {
+		return created, err
+	}
+	req, err := r.client.newRequest("POST", r.url(), item)
+	if err != nil {
+		return created, err
+	}
+	if err := r.client.makeRequest(ctx, req, created); err != nil {
+		return created, err
+	}
+	return created, nil
+}
+
+// Update updates the record with the given id, which may also be a keyable such as
+// extid-{{externalId}} or srcid-{{sourceId}}.  The id is used as it is, so UpdateByKey should be
+// preferred for keyables that need escaping.
+func (r *Resource[T, O]) Update(ctx context.Context, id string, item T) (*T, error) {
+	return r.update(r.op(ctx, "Update"), id, item)
+}
+
+// UpdateByKey updates the record identified by key.
+func (r *Resource[T, O]) UpdateByKey(ctx context.Context, key Key, item T) (*T, error) {
+	p, err := key.path()
+	if err != nil {
+		return new(T), err
+	}
+	return r.update(r.op(ctx, "UpdateByKey"), p, item)
+}
+
+// UpdateFields applies a partial update, such as a CompanyUpdate, to the record identified by key,
+// sending only the fields it contains.
+func (r *Resource[T, O]) UpdateFields(ctx context.Context, key Key, update PartialUpdate) (*T, error) {
+	if err := update.Err(); err != nil {
+		return new(T), err
+	}
+	p, err := key.path()
+	if err != nil {
+		return new(T), err
+	}
+	return r.update(r.op(ctx, "UpdateFields"), p, update)
+}
+
+// UpdateIfChanged fetches the record identified by key and updates it with only the fields of desired
+// that differ, as reported by Diff.  Nothing is sent if there are no changes.  It returns the record,
+// updated or not, and the changes made.
+func (r *Resource[T, O]) UpdateIfChanged(ctx context.Context, key Key, desired T) (*T, []Change, error) {
+	ctx = r.op(ctx, "UpdateIfChanged")
+	p, err := key.path()
+	if err != nil {
+		return new(T), nil, err
+	}
+	current, err := r.get(ctx, p)
+	if err != nil {
+		return current, nil, err
+	}
+	changes := Diff(current, &desired)
+	if len(changes) == 0 {
+		return current, nil, nil
+	}
+	update := patchFromChanges(reflect.TypeOf(desired), changes)
+	if err := update.Err(); err != nil {
+		return current, nil, err
+	}
+	updated, err := r.update(ctx, p, update)
+	if err != nil {
+		return updated, nil, err
+	}
+	return updated, changes, nil
+}
+
+// update sends body, which may be a T or a PartialUpdate, to the record with the given id.
+func (r *Resource[T, O]) update(ctx context.Context, id string, body interface{}) (*T, error) {
+	updated := new(T)
+	req, err := r.client.newRequest("PUT", r.url(id), body)
+	if err != nil {
+		return updated, err
+	}
+	if err := r.client.makeRequest(ctx, req, updated); err != nil {
+		return updated, err
+	}
+	return updated, nil
+}
+
+// Get returns the record with the given id, which may also be a keyable such as
+// extid-{{externalId}} or srcid-{{sourceId}}.  The id is used as it is, so GetByKey should be
+// preferred for keyables that need escaping.
+func (r *Resource[T, O]) Get(ctx context.Context, id string) (*T, error) {
+	return r.get(r.op(ctx, "Get"), id)
+}
+
+// GetByKey returns the record identified by key.
+func (r *Resource[T, O]) GetByKey(ctx context.Context, key Key) (*T, error) {
+	p, err := key.path()
+	if err != nil {
+		return new(T), err
+	}
+	return r.get(r.op(ctx, "GetByKey"), p)
+}
+
+// GetByExternalID returns the record with the given external ID.
+func (r *Resource[T, O]) GetByExternalID(ctx context.Context, externalID string) (*T, error) {
+	return r.GetByKey(r.op(ctx, "GetByExternalID"), ByExternalID(externalID))
+}
+
+// GetBySourceID returns the record with the given source ID.
+func (r *Resource[T, O]) GetBySourceID(ctx context.Context, sourceID string) (*T, error) {
+	return r.GetByKey(r.op(ctx, "GetBySourceID"), BySourceID(sourceID))
+}
+
+// Result is the outcome of fetching a single record in a GetMany.
+type Result[T any] struct {
+	// Key identifies the record requested.
+	Key Key
+	// Value is the record, or nil if Err is set.
+	Value *T
+	// Err is the error fetching the record, e.g. ErrNotFound.
+	Err error
+}
+
+// GetMany returns the records identified by keys, fetching up to the client's batch concurrency at
+// once (see WithBatchConcurrency).  The results are in the same order as keys, and errors, such as
+// ErrNotFound, are reported per record rather than failing the batch.  If ctx is done before all the
+// records are fetched, the remaining results hold the context's error.  Response metadata is not
+// captured for the individual requests, as they run concurrently, so a Response passed in ctx using
+// WithResponse is left unchanged.
+func (r *Resource[T, O]) GetMany(ctx context.Context, keys []Key) []Result[T] {
+	ctx = withoutResponse(r.op(ctx, "GetMany"))
+	results := make([]Result[T], len(keys))
+	workers := min(max(r.client.batch, 1), len(keys))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i].Value, results[i].Err = r.GetByKey(ctx, keys[i])
+				if results[i].Err != nil {
+					results[i].Value = nil
+				}
+			}
+		}()
+	}
+	for i, key := range keys {
+		results[i].Key = key
+		if ctx.Err() == nil {
+			select {
+			case next <- i:
+				continue
+			case <-ctx.Done():
+			}
+		}
+		results[i].Err = ctx.Err()
+	}
+	close(next)
+	wg.Wait()
+	return results
+}
+
+func (r *Resource[T, O]) get(ctx context.Context, id string) (*T, error) {
+	item := new(T)
+	req, err := r.client.newRequest("GET", r.url(id), nil)
+	if err != nil {
+		return item, err
+	}
+	if err := r.client.makeRequest(ctx, req, item); err != nil {
+		return item, err
+	}
+	return item, nil
+}
+
+// List returns the records matching the options provided.
+func (r *Resource[T, O]) List(ctx context.Context, options ...*O) ([]*T, error) {
+	ctx = r.op(ctx, "List")
+	items := []*T{}
+	url := r.url()
+	for _, option := range options {
+		var err error
+		url, err = addOptions(url, option)
+		if err != nil {
+			return items, err
+		}
+	}
+	req, err := r.client.newRequest("GET", url, nil)
+	if err != nil {
+		return items, err
+	}
+	if err := r.client.makeRequest(ctx, req, &items); err != nil {
+		return items, err
+	}
+	return items, nil
+}
+
+// ListEach calls fn with each record matching the options provided, decoding the response one
+// record at a time rather than holding them all in memory.  It stops and returns the error if fn
+// returns one.
+func (r *Resource[T, O]) ListEach(ctx context.Context, fn func(*T) error, options ...*O) error {
+	ctx = r.op(ctx, "ListEach")
+	url := r.url()
+	for _, option := range options {
+		var err error
+		url, err = addOptions(url, option)
+		if err != nil {
+			return err
+		}
+	}
+	req, err := r.client.newRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	return r.client.makeRequest(ctx, req, arrayStream[T]{fn: fn})
+}
+
+// Delete deletes the record with the given id.  The id is used as it is, so DeleteByKey should be
+// preferred for keyables that need escaping.
+func (r *Resource[T, O]) Delete(ctx context.Context, id string) (*DeleteResponse, error) {
+	return r.delete(r.op(ctx, "Delete"), id)
+}
+
+// DeleteByKey deletes the record identified by key.
+func (r *Resource[T, O]) DeleteByKey(ctx context.Context, key Key) (*DeleteResponse, error) {
+	p, err := key.path()
+	if err != nil {
+		return nil, err
+	}
+	return r.delete(r.op(ctx, "DeleteByKey"), p)
+}
+
+func (r *Resource[T, O]) delete(ctx context.Context, id string) (*DeleteResponse, error) {
+	req, err := r.client.newRequest("DELETE", r.url(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	dr := &DeleteResponse{}
+	if err := r.client.makeRequest(ctx, req, dr); err != nil {
+		return dr, err
+	}
+	return dr, nil
+}
+
+// BulkUpsert updates or inserts the records provided, after validating them if T has a Validate
+// method.
+// Note there is an upper limit of 50,000 items per request.
+func (r *Resource[T, O]) BulkUpsert(ctx context.Context, items []T) (*UpsertResponse, error) {
+	ctx = r.op(ctx, "BulkUpsert")
+	if err := validateBulk(r.client, items); err != nil {
+		return nil, err
+	}
+	req, err := r.client.newRequest("PUT", r.url(), items)
+	if err != nil {
+		return nil, err
+	}
+	ur := &UpsertResponse{}
+	if err := r.client.makeRequest(ctx, req, ur); err != nil {
+		return ur, err
+	}
+	return ur, nil
+}
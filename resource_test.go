@@ -0,0 +1,144 @@
+package planhat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testProject struct {
+	ID   *string `json:"_id,omitempty"`
+	Name *string `json:"name,omitempty"`
+}
+
+type testProjectListOptions struct {
+	Limit *int `url:"limit,omitempty"`
+}
+
+func TestResource(t *testing.T) {
+	var got []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = append(got, fmt.Sprintf("%s %s %s", r.Method, r.URL.RequestURI(), body))
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/projects":
+			fmt.Fprint(w, `[{"_id":"1"},{"_id":"2"}]`)
+		case r.Method == "PUT" && r.URL.Path == "/projects":
+			fmt.Fprint(w, `{"created":1,"updated":1}`)
+		case r.Method == "DELETE":
+			fmt.Fprint(w, `{"n":1,"ok":1,"deletedCount":1}`)
+		default:
+			var p testProject
+			json.Unmarshal(body, &p)
+			p.ID = String("1")
+			json.NewEncoder(w).Encode(p)
+		}
+	}))
+	defer ts.Close()
+
+	c, _ := New("key", WithBaseURL(ts.URL))
+	r := NewResource[testProject, testProjectListOptions](c, "ProjectService", "projects")
+	ctx := context.Background()
+
+	p, err := r.Create(ctx, testProject{Name: String("Launch")})
+	if err != nil || *p.ID != "1" || *p.Name != "Launch" {
+		t.Errorf("unexpected create result: %+v, %v", p, err)
+	}
+	r.Update(ctx, "1", testProject{Name: String("Relaunch")})
+	r.Get(ctx, "1")
+	r.GetByExternalID(ctx, "ext")
+	r.GetBySourceID(ctx, "src")
+	ps, err := r.List(ctx, &testProjectListOptions{Limit: Int(2)})
+	if err != nil || len(ps) != 2 {
+		t.Errorf("unexpected list result: %+v, %v", ps, err)
+	}
+	if dr, err := r.Delete(ctx, "1"); err != nil || dr.DeletedCount != 1 {
+		t.Errorf("unexpected delete result: %+v, %v", dr, err)
+	}
+	if ur, err := r.BulkUpsert(ctx, []testProject{{Name: String("a")}, {ID: String("1")}}); err != nil || ur.Created != 1 || ur.Updated != 1 {
+		t.Errorf("unexpected upsert result: %+v, %v", ur, err)
+	}
+
+	want := []string{
+		`POST /projects {"name":"Launch"}`,
+		`PUT /projects/1 {"name":"Relaunch"}`,
+		`GET /projects/1 `,
+		`GET /projects/extid-ext `,
+		`GET /projects/srcid-src `,
+		`GET /projects?limit=2 `,
+		`DELETE /projects/1 `,
+		`PUT /projects [{"name":"a"},{"_id":"1"}]`,
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got requests:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestResourceGetMany(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		id := strings.TrimPrefix(r.URL.Path, "/projects/")
+		if id == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"_id":%q}`, id)
+	}))
+	defer ts.Close()
+
+	c, _ := New("key", WithBaseURL(ts.URL), WithRateLimit(1000, 10), WithBatchConcurrency(3))
+	r := NewResource[testProject, testProjectListOptions](c, "ProjectService", "projects")
+
+	var keys []Key
+	for i := 0; i < 10; i++ {
+		keys = append(keys, ByID(fmt.Sprint(i)))
+	}
+	keys = append(keys, ByID("missing"), ByExternalID("a/b"))
+	var resp Response
+	results := r.GetMany(WithResponse(context.Background(), &resp), keys)
+	if resp.Response != nil {
+		t.Errorf("GetMany captured response metadata: %+v", resp)
+	}
+	if len(results) != len(keys) {
+		t.Fatalf("got %d results, want %d", len(results), len(keys))
+	}
+	for i, res := range results[:10] {
+		if res.Err != nil || res.Key != keys[i] || *res.Value.ID != fmt.Sprint(i) {
+			t.Errorf("result %d = %+v", i, res)
+		}
+	}
+	if res := results[10]; res.Err != ErrNotFound || res.Value != nil {
+		t.Errorf("missing result = %+v", res)
+	}
+	if res := results[11]; res.Err != nil || *res.Value.ID != "extid-a/b" {
+		t.Errorf("external id result = %+v", res)
+	}
+	if p := peak.Load(); p > 3 {
+		t.Errorf("peak concurrency = %d, want <= 3", p)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, res := range r.GetMany(ctx, keys) {
+		if !errors.Is(res.Err, context.Canceled) {
+			t.Errorf("result after cancel = %+v", res)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package planhat
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Response holds metadata about the HTTP response to an API call.  The embedded http.Response
+// provides the status and headers; its body has already been read and closed.
+type Response struct {
+	*http.Response
+
+	// RateLimit holds any rate limit information returned by planhat.
+	RateLimit RateLimit
+
+	// Duration of the call, including any time spent waiting on the rate limiter and retrying.
+	Duration time.Duration
+
+	// Attempts is the number of times the request was sent.
+	Attempts int
+}
+
+// RateLimit represents the rate limit headers returned with a response.  Fields are zero if the
+// corresponding header was not present.
+type RateLimit struct {
+	// Limit is the number of requests allowed in the current window.
+	Limit int
+
+	// Remaining is the number of requests left in the current window.
+	Remaining int
+
+	// Reset is the time at which the current window resets.
+	Reset time.Time
+
+	// RetryAfter is the delay requested by planhat before trying again, usually with a 429 response.
+	RetryAfter time.Duration
+}
+
+type responseKey struct{}
+
+// WithResponse returns a context that captures the response metadata of the API call it is used
+// with into resp.  This allows the status, headers and rate limit information to be inspected
+// without changing the signature of service methods:
+//
+//	var resp planhat.Response
+//	companies, err := ph.CompanyService.List(planhat.WithResponse(ctx, &resp))
+//	log.Println(resp.StatusCode, resp.RateLimit.Remaining)
+//
+// If the context is used for several calls, resp holds the metadata for the last one.  Methods that
+// make requests concurrently, such as GetMany, don't capture their metadata.
+func WithResponse(ctx context.Context, resp *Response) context.Context {
+	return context.WithValue(ctx, responseKey{}, resp)
+}
+
+// withoutResponse returns a context that does not capture response metadata, for requests made
+// concurrently that would otherwise all write to the same Response.
+func withoutResponse(ctx context.Context) context.Context {
+	if responseFromContext(ctx) == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, responseKey{}, (*Response)(nil))
+}
+
+// responseFromContext returns the Response being captured for ctx, or nil.
+func responseFromContext(ctx context.Context) *Response {
+	resp, _ := ctx.Value(responseKey{}).(*Response)
+	return resp
+}
+
+// parseRateLimit parses the rate limit headers from a response.  Both the X-RateLimit-* and
+// RateLimit-* forms are understood, with reset given either as seconds from now or a unix time.
+func parseRateLimit(res *http.Response) RateLimit {
+	rl := RateLimit{RetryAfter: retryAfter(res)}
+	h := res.Header
+	header := func(name string) string {
+		if v := h.Get("X-RateLimit-" + name); v != "" {
+			return v
+		}
+		return h.Get("RateLimit-" + name)
+	}
+	if v, err := strconv.Atoi(header("Limit")); err == nil {
+		rl.Limit = v
+	}
+	if v, err := strconv.Atoi(header("Remaining")); err == nil {
+		rl.Remaining = v
+	}
+	if v, err := strconv.ParseInt(header("Reset"), 10, 64); err == nil {
+		// Values this large can only be unix timestamps rather than a delay in seconds.
+		if v > 1e9 {
+			rl.Reset = time.Unix(v, 0)
+		} else {
+			rl.Reset = time.Now().Add(time.Duration(v) * time.Second)
+		}
+	}
+	return rl
+}
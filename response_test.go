@@ -0,0 +1,69 @@
+package planhat
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestResponse_Capture(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("X-RateLimit-Limit", "200")
+		w.Header().Set("X-RateLimit-Remaining", "199")
+		w.Header().Set("X-RateLimit-Reset", "1700000000")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"_id":"abc","name":"Acme"}`)
+	}))
+	defer ts.Close()
+
+	c, _ := New("key", WithBaseURL(ts.URL), WithRetry(1, time.Millisecond, time.Millisecond))
+	var resp Response
+	co, err := c.CompanyService.Create(WithResponse(context.Background(), &resp), Company{Name: String("Acme")})
+	if err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+	if co.GetID() != "abc" {
+		t.Errorf("created company should be decoded, got id: %q", co.GetID())
+	}
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("got status: %d; want %d", resp.StatusCode, http.StatusCreated)
+	}
+	if resp.Attempts != 2 {
+		t.Errorf("got attempts: %d; want 2", resp.Attempts)
+	}
+	if resp.Duration <= 0 {
+		t.Errorf("expected positive duration")
+	}
+	want := RateLimit{Limit: 200, Remaining: 199, Reset: time.Unix(1700000000, 0)}
+	if resp.RateLimit != want {
+		t.Errorf("got rate limit: %+v; want %+v", resp.RateLimit, want)
+	}
+}
+
+func TestResponse_CaptureOnError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("RateLimit-Remaining", "0")
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	c, _ := New("key", WithBaseURL(ts.URL))
+	var resp Response
+	if _, err := c.CompanyService.Get(WithResponse(context.Background(), &resp), "1"); err != ErrUnknown {
+		t.Errorf("got error: %v; want %v", err, ErrUnknown)
+	}
+	if resp.StatusCode != http.StatusTooManyRequests || resp.RateLimit.RetryAfter != 30*time.Second {
+		t.Errorf("unexpected response: status %d, rate limit %+v", resp.StatusCode, resp.RateLimit)
+	}
+}
@@ -0,0 +1,153 @@
+package planhat
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// retryPolicy holds the retry configuration for a client.  The zero value disables retries.
+type retryPolicy struct {
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// backoff returns how long to wait before the given retry, where retry 1 is the first retry.
+func (p retryPolicy) backoff(retry int, res *http.Response) time.Duration {
+	wait := p.minBackoff
+	for i := 1; i < retry && wait < p.maxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > p.maxBackoff {
+		wait = p.maxBackoff
+	}
+	if ra := retryAfter(res); ra > wait {
+		wait = ra
+	}
+	return wait
+}
+
+// shouldRetry reports whether a request that resulted in res or err should be tried again.
+func (p retryPolicy) shouldRetry(req *http.Request, retries int, res *http.Response, err error) bool {
+	if retries >= p.maxRetries {
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false
+		}
+		return idempotent(req.Method)
+	}
+	switch res.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return idempotent(req.Method)
+	}
+	return false
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retryAfter returns the delay requested by a Retry-After header in seconds, or zero.
+func retryAfter(res *http.Response) time.Duration {
+	if res == nil {
+		return 0
+	}
+	secs, err := strconv.Atoi(res.Header.Get("Retry-After"))
+	if err != nil || secs < 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// do sends the request, waiting on the rate limiter before each attempt and retrying according
+// to the client's retry policy.
+func (c *Client) do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	trace := ContextClientTrace(ctx)
+	captured := responseFromContext(ctx)
+	endpoint := c.endpointFor(req)
+	lim := c.limiterFor(endpoint)
+	breaker := c.breakers[endpoint]
+	for retries := 0; ; retries++ {
+		if breaker != nil && breaker.State() == CircuitOpen {
+			return nil, ErrCircuitOpen
+		}
+		if captured != nil {
+			captured.Attempts = retries + 1
+		}
+		start := time.Now()
+		if err := wait(ctx, lim); err != nil {
+			return nil, err
+		}
+		if trace != nil && trace.RateLimitWait != nil {
+			trace.RateLimitWait(retries+1, time.Since(start))
+		}
+		if breaker != nil {
+			if err := breaker.allow(); err != nil {
+				return nil, err
+			}
+		}
+
+		rc := req.WithContext(ctx)
+		if retries > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			rc.Body = body
+		}
+		start = time.Now()
+		res, err := c.HTTPClient.Do(rc)
+		decompress(res)
+		c.logAttempt(ctx, rc, res, err, retries+1, time.Since(start))
+		if breaker != nil {
+			breaker.record(res, err)
+		}
+		if o, ok := lim.(ResponseObserver); ok && res != nil {
+			o.ObserveResponse(res)
+		}
+		if !c.retry.shouldRetry(req, retries, res, err) {
+			return res, err
+		}
+
+		wait := c.retry.backoff(retries+1, res)
+		if c.logger != nil {
+			attrs := []any{"method", req.Method, "url", c.redactURL(req.URL.String()), "attempt", retries + 1, "wait", wait}
+			if err != nil {
+				attrs = append(attrs, "error", err)
+			} else {
+				attrs = append(attrs, "status", res.StatusCode)
+			}
+			c.logger.WarnContext(ctx, "planhat: retrying request", attrs...)
+		}
+		if trace != nil && trace.Retry != nil {
+			trace.Retry(retries+1, wait, res, err)
+		}
+		if res != nil {
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,573 @@
+package planhat
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DropPolicy decides what a MetricSpool does when a write would take it over its maximum size.
+type DropPolicy int
+
+// Drop policies supported by MetricSpool.
+const (
+	// DropOldest deletes the oldest unsent segments to make room for new metrics.
+	DropOldest DropPolicy = iota
+	// DropNewest rejects new metrics with ErrSpoolFull.
+	DropNewest
+)
+
+// SpoolOptions configures a MetricSpool.
+type SpoolOptions struct {
+	// MaxBytes is the maximum size of the unsent metrics on disk.  Defaults to 256 MiB.
+	MaxBytes int64
+
+	// SegmentBytes is the size at which a new segment file is started.  Defaults to 16 MiB.
+	SegmentBytes int64
+
+	// Drop is applied when a write would exceed MaxBytes.  Defaults to DropOldest.
+	Drop DropPolicy
+
+	// BatchSize is the maximum number of metrics sent per request when flushing.  Defaults to 5,000.
+	BatchSize int
+
+	// Sync calls fsync after every write and checkpoint, trading throughput for durability should
+	// the machine, rather than just the process, fail.
+	Sync bool
+
+	// NormaliseDimensions normalises the DimensionID of each metric written using
+	// NormaliseDimensionID before it is validated.  It is enabled automatically if the spool is in
+	// front of a MetricsService whose client was created using WithDimensionNormalisation.
+	NormaliseDimensions bool
+}
+
+// SpoolStats reports the state of a MetricSpool.
+type SpoolStats struct {
+	// Segments is the number of segment files on disk.
+	Segments int
+	// PendingBytes is the size of the metrics waiting to be sent.
+	PendingBytes int64
+	// DroppedBytes is the size of the metrics dropped to stay within MaxBytes since the spool was opened.
+	DroppedBytes int64
+	// Skipped is the number of metrics skipped since the spool was opened because they were corrupt
+	// on disk, or rejected as invalid or with ErrBadRequest when sent.
+	Skipped int
+}
+
+const (
+	spoolSegmentExt = ".seg"
+	spoolCheckpoint = "checkpoint"
+)
+
+// spoolPos is a position in the spool: an offset within a segment.
+type spoolPos struct {
+	Segment uint64 `json:"segment"`
+	Offset  int64  `json:"offset"`
+}
+
+func (p spoolPos) before(q spoolPos) bool {
+	return p.Segment < q.Segment || p.Segment == q.Segment && p.Offset < q.Offset
+}
+
+type spoolSegment struct {
+	seq  uint64
+	size int64
+}
+
+// MetricSpool is a durable queue on disk in front of a MetricsAPI, such as the MetricsService, so
+// that metrics are not lost if planhat is unreachable or the process restarts.  Metrics written are
+// appended to segment files in dir, and Flush sends them in order using the wrapped BulkUpsert,
+// recording its progress in a checkpoint file so that a spool reopened on the same directory resumes
+// where it left off.  Metrics are sent at least once: those sent just before a crash may be sent again.
+//
+// BulkUpsert writes to the spool rather than sending, so a MetricSpool can be used wherever a
+// MetricsAPI is expected, such as by a MetricAggregator.  All other methods are passed straight
+// through.  It is safe for concurrent use, but only one MetricSpool may use a directory at a time.
+type MetricSpool struct {
+	MetricsAPI
+	dir  string
+	opts SpoolOptions
+
+	flushMu sync.Mutex // held by Flush so that batches are sent in order
+
+	mu      sync.Mutex
+	w       *os.File // the last segment, which writes are appended to
+	segs    []spoolSegment
+	cp      spoolPos // the position of the first unsent record
+	dropped int64
+	skipped int
+	closed  bool
+}
+
+var _ MetricsAPI = (*MetricSpool)(nil)
+
+// OpenMetricSpool opens the spool in dir, creating it if necessary, in front of api.  Any metrics
+// left unsent by a previous process are sent by the next Flush.
+func OpenMetricSpool(dir string, api MetricsAPI, opts SpoolOptions) (*MetricSpool, error) {
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = 256 << 20
+	}
+	if opts.SegmentBytes <= 0 {
+		opts.SegmentBytes = 16 << 20
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = maxMetricsBatch
+	}
+	opts.NormaliseDimensions = opts.NormaliseDimensions || normalisesDimensions(api)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	s := &MetricSpool{MetricsAPI: api, dir: dir, opts: opts}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load reads the checkpoint and segments from disk, removing segments already sent and any partly
+// written record left at the end of the last segment by a crash.
+func (s *MetricSpool) load() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	var seqs []uint64
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), spoolSegmentExt)
+		if !ok {
+			continue
+		}
+		if seq, err := strconv.ParseUint(name, 10, 64); err == nil {
+			seqs = append(seqs, seq)
+		}
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+
+	b, err := os.ReadFile(filepath.Join(s.dir, spoolCheckpoint))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err == nil {
+		if err := json.Unmarshal(b, &s.cp); err != nil {
+			return fmt.Errorf("planhat: reading spool checkpoint: %w", err)
+		}
+	}
+
+	for _, seq := range seqs {
+		if seq < s.cp.Segment {
+			if err := os.Remove(s.segmentPath(seq)); err != nil {
+				return err
+			}
+			continue
+		}
+		fi, err := os.Stat(s.segmentPath(seq))
+		if err != nil {
+			return err
+		}
+		s.segs = append(s.segs, spoolSegment{seq: seq, size: fi.Size()})
+	}
+	if len(s.segs) == 0 {
+		s.segs = []spoolSegment{{seq: max(s.cp.Segment, 1)}}
+	}
+	if s.cp.Segment < s.segs[0].seq {
+		s.cp = spoolPos{Segment: s.segs[0].seq}
+	}
+
+	last := &s.segs[len(s.segs)-1]
+	if err := s.repair(last); err != nil {
+		return err
+	}
+	if s.cp.Segment == last.seq {
+		s.cp.Offset = min(s.cp.Offset, last.size)
+	}
+	s.w, err = os.OpenFile(s.segmentPath(last.seq), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	return err
+}
+
+// repair truncates seg after its last complete record.
+func (s *MetricSpool) repair(seg *spoolSegment) error {
+	b, err := os.ReadFile(s.segmentPath(seg.seq))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if size := int64(bytes.LastIndexByte(b, '\n') + 1); size != seg.size {
+		if err := os.Truncate(s.segmentPath(seg.seq), size); err != nil {
+			return err
+		}
+		seg.size = size
+	}
+	return nil
+}
+
+func (s *MetricSpool) segmentPath(seq uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%020d%s", seq, spoolSegmentExt))
+}
+
+// encodeSpoolRecord encodes metrics as a line holding the CRC-32 of the JSON followed by the JSON.
+func encodeSpoolRecord(metrics []Metric) ([]byte, error) {
+	b, err := json.Marshal(metrics)
+	if err != nil {
+		return nil, err
+	}
+	return append(fmt.Appendf(nil, "%08x ", crc32.ChecksumIEEE(b)), append(b, '\n')...), nil
+}
+
+func decodeSpoolRecord(line []byte) ([]Metric, error) {
+	sum, b, ok := bytes.Cut(bytes.TrimSuffix(line, []byte("\n")), []byte(" "))
+	if !ok {
+		return nil, errors.New("missing checksum")
+	}
+	if want, err := strconv.ParseUint(string(sum), 16, 32); err != nil || uint32(want) != crc32.ChecksumIEEE(b) {
+		return nil, errors.New("checksum mismatch")
+	}
+	var metrics []Metric
+	err := json.Unmarshal(b, &metrics)
+	return metrics, err
+}
+
+// Write validates metrics and appends them to the spool.  It returns a *BulkValidationError, and
+// writes nothing, if any are invalid.  If the spool is full, the oldest metrics are dropped or
+// ErrSpoolFull returned depending on the drop policy.  ErrSpoolFull is always returned if the
+// metrics alone would exceed MaxBytes.
+func (s *MetricSpool) Write(metrics ...Metric) error {
+	if s.opts.NormaliseDimensions {
+		metrics = normaliseDimensions(metrics)
+	}
+	var errs []ItemError
+	for i := range metrics {
+		if err := metrics[i].Validate(); err != nil {
+			errs = append(errs, ItemError{Index: i, Err: err})
+		}
+	}
+	if err := bulkError(len(metrics), errs); err != nil {
+		return err
+	}
+	// Records are kept to a batch in size, so that each can be sent in a single request.
+	var records [][]byte
+	var n int64
+	for start := 0; start < len(metrics); start += s.opts.BatchSize {
+		rec, err := encodeSpoolRecord(metrics[start:min(start+s.opts.BatchSize, len(metrics))])
+		if err != nil {
+			return err
+		}
+		records = append(records, rec)
+		n += int64(len(rec))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return os.ErrClosed
+	}
+	if err := s.makeRoom(n); err != nil {
+		return err
+	}
+	for _, rec := range records {
+		last := &s.segs[len(s.segs)-1]
+		if last.size > 0 && last.size+int64(len(rec)) > s.opts.SegmentBytes {
+			if err := s.rotate(); err != nil {
+				return err
+			}
+			last = &s.segs[len(s.segs)-1]
+		}
+		if _, err := s.w.Write(rec); err != nil {
+			return err
+		}
+		last.size += int64(len(rec))
+	}
+	if s.opts.Sync {
+		return s.w.Sync()
+	}
+	return nil
+}
+
+// BulkUpsert writes metrics to the spool, to be sent by the next Flush.  The response reports the
+// metrics spooled as processed.
+func (s *MetricSpool) BulkUpsert(ctx context.Context, metrics []Metric) (*UpsertMetricsResponse, error) {
+	if err := s.Write(metrics...); err != nil {
+		return nil, err
+	}
+	return &UpsertMetricsResponse{Processed: len(metrics)}, nil
+}
+
+// pending returns the size of the unsent records.  s.mu must be held.
+func (s *MetricSpool) pending() int64 {
+	var n int64
+	for _, seg := range s.segs {
+		n += seg.size
+	}
+	return n - s.cp.Offset
+}
+
+// makeRoom applies the drop policy so that n more bytes fit within MaxBytes.  A write larger than
+// MaxBytes is rejected with ErrSpoolFull without dropping anything.  s.mu must be held.
+func (s *MetricSpool) makeRoom(n int64) error {
+	if n > s.opts.MaxBytes || s.opts.Drop == DropNewest && s.pending()+n > s.opts.MaxBytes {
+		s.dropped += n
+		return ErrSpoolFull
+	}
+	for s.pending()+n > s.opts.MaxBytes && s.pending() > 0 {
+		if len(s.segs) == 1 {
+			if err := s.rotate(); err != nil {
+				return err
+			}
+		}
+		s.dropped += s.segs[0].size - s.cp.Offset
+		if err := s.setCheckpoint(spoolPos{Segment: s.segs[1].seq}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rotate starts a new segment.  s.mu must be held.
+func (s *MetricSpool) rotate() error {
+	seq := s.segs[len(s.segs)-1].seq + 1
+	w, err := os.OpenFile(s.segmentPath(seq), os.O_WRONLY|os.O_APPEND|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := s.w.Close(); err != nil {
+		w.Close()
+		return err
+	}
+	s.w = w
+	s.segs = append(s.segs, spoolSegment{seq: seq})
+	return nil
+}
+
+// setCheckpoint records that everything before pos has been sent or dropped and removes the segments
+// no longer needed.  Positions before the current checkpoint, which can happen when records being
+// sent are dropped, are ignored.  s.mu must be held.
+func (s *MetricSpool) setCheckpoint(pos spoolPos) error {
+	if !s.cp.before(pos) {
+		return nil
+	}
+	b, _ := json.Marshal(pos)
+	tmp := filepath.Join(s.dir, spoolCheckpoint+".tmp")
+	if err := writeFile(tmp, b, s.opts.Sync); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(s.dir, spoolCheckpoint)); err != nil {
+		return err
+	}
+	s.cp = pos
+	for len(s.segs) > 1 && s.segs[0].seq < pos.Segment {
+		if err := os.Remove(s.segmentPath(s.segs[0].seq)); err != nil {
+			return err
+		}
+		s.segs = s.segs[1:]
+	}
+	return nil
+}
+
+// checkpoint sets the checkpoint after a batch, counting the metrics skipped in it.  s.mu must be held.
+func (s *MetricSpool) checkpoint(pos spoolPos, skipped int) error {
+	if !s.cp.before(pos) {
+		return nil
+	}
+	if err := s.setCheckpoint(pos); err != nil {
+		return err
+	}
+	s.skipped += skipped
+	return nil
+}
+
+func writeFile(name string, b []byte, sync bool) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if sync {
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// next reads the records from the checkpoint, up to BatchSize metrics, returning them with the
+// position after them and the number of corrupt records skipped.  s.mu must be held.
+func (s *MetricSpool) next() ([]Metric, spoolPos, int, error) {
+	var metrics []Metric
+	corrupt := 0
+	pos := s.cp
+	for i := 0; i < len(s.segs); i++ {
+		seg := s.segs[i]
+		if seg.seq < pos.Segment {
+			continue
+		}
+		if pos.Segment < seg.seq {
+			pos = spoolPos{Segment: seg.seq}
+		}
+		if pos.Offset >= seg.size {
+			continue
+		}
+		f, err := os.Open(s.segmentPath(seg.seq))
+		if err != nil {
+			return nil, pos, 0, err
+		}
+		r := bufio.NewReader(io.NewSectionReader(f, pos.Offset, seg.size-pos.Offset))
+		for {
+			line, err := r.ReadBytes('\n')
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				f.Close()
+				return nil, pos, 0, err
+			}
+			rec, err := decodeSpoolRecord(line)
+			if err != nil {
+				corrupt++
+				pos.Offset += int64(len(line))
+				continue
+			}
+			if len(metrics) > 0 && len(metrics)+len(rec) > s.opts.BatchSize {
+				f.Close()
+				return metrics, pos, corrupt, nil
+			}
+			metrics = append(metrics, rec...)
+			pos.Offset += int64(len(line))
+		}
+		f.Close()
+	}
+	return metrics, pos, corrupt, nil
+}
+
+// Flush sends the spooled metrics in order using the wrapped BulkUpsert, in batches of up to
+// BatchSize, checkpointing after each.  It stops at the first error, such as a network or server
+// error, leaving the remaining metrics to be sent by the next Flush, except for errors which sending
+// the batch again can't fix.  Metrics rejected as invalid, such as by a DimensionRegistry, are
+// skipped and the rest of their batch sent again, and a batch rejected by planhat with ErrBadRequest
+// is skipped.  Skipped metrics are counted in Stats, and the last such error is returned once the
+// remaining batches have been sent.  The responses of the batches sent are combined.
+func (s *MetricSpool) Flush(ctx context.Context) (*UpsertMetricsResponse, error) {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+	resp := &UpsertMetricsResponse{}
+	var rejected error
+	for {
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			return resp, os.ErrClosed
+		}
+		metrics, end, corrupt, err := s.next()
+		if err == nil && len(metrics) == 0 {
+			// Move past any corrupt records at the end.
+			err = s.checkpoint(end, corrupt)
+		}
+		s.mu.Unlock()
+		if err != nil || len(metrics) == 0 {
+			if err == nil {
+				err = rejected
+			}
+			return resp, err
+		}
+
+		r, err := s.MetricsAPI.BulkUpsert(ctx, metrics)
+		var bve *BulkValidationError
+		for errors.As(err, &bve) && len(bve.Items) > 0 && len(bve.Items) < len(metrics) {
+			// Send the batch again without the invalid metrics.
+			rejected = err
+			corrupt += len(bve.Items)
+			metrics = withoutItems(metrics, bve.Items)
+			r, err = s.MetricsAPI.BulkUpsert(ctx, metrics)
+		}
+		if err != nil && !errors.Is(err, ErrValidation) && !errors.Is(err, ErrBadRequest) {
+			return resp, err
+		}
+		if err != nil {
+			// The whole batch is invalid, the invalid metrics can't be identified, or planhat
+			// rejected it.
+			rejected = err
+			corrupt += len(metrics)
+		} else if r != nil {
+			resp.Processed += r.Processed
+			resp.Errors = append(resp.Errors, r.Errors...)
+		}
+
+		s.mu.Lock()
+		err = s.checkpoint(end, corrupt)
+		s.mu.Unlock()
+		if err != nil {
+			return resp, err
+		}
+	}
+}
+
+// withoutItems returns metrics without those at the indexes of items.
+func withoutItems(metrics []Metric, items []ItemError) []Metric {
+	skip := make(map[int]bool, len(items))
+	for _, item := range items {
+		skip[item.Index] = true
+	}
+	out := make([]Metric, 0, len(metrics)-len(skip))
+	for i, m := range metrics {
+		if !skip[i] {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// Run calls Flush every interval until ctx is done, passing any errors to onError if it is not nil.
+// It blocks, so is usually run in its own goroutine.
+func (s *MetricSpool) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if _, err := s.Flush(ctx); err != nil && onError != nil && ctx.Err() == nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// Stats returns the spool statistics.
+func (s *MetricSpool) Stats() SpoolStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return SpoolStats{
+		Segments:     len(s.segs),
+		PendingBytes: s.pending(),
+		DroppedBytes: s.dropped,
+		Skipped:      s.skipped,
+	}
+}
+
+// Close closes the spool.  Unsent metrics remain on disk to be sent when it is next opened.
+func (s *MetricSpool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return s.w.Close()
+}
@@ -0,0 +1,207 @@
+package planhat
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func spoolMetric(ext string) Metric {
+	return Metric{DimensionID: String("logins"), Value: Float64(1), ExternalID: String(ext)}
+}
+
+func sentIDs(f *fakeMetrics) []string {
+	var ids []string
+	for _, batch := range f.sent {
+		for _, m := range batch {
+			ids = append(ids, m.GetExternalID())
+		}
+	}
+	return ids
+}
+
+func TestMetricSpoolReplay(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	fake := &fakeMetrics{err: errors.New("unreachable")}
+	opts := SpoolOptions{SegmentBytes: 200, BatchSize: 2}
+	s, err := OpenMetricSpool(dir, fake, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Write(spoolMetric("a"), spoolMetric("b"), spoolMetric("c")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.BulkUpsert(ctx, []Metric{spoolMetric("d")}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Flush(ctx); err == nil {
+		t.Fatal("expected error")
+	}
+	if st := s.Stats(); st.Segments < 2 || st.PendingBytes == 0 {
+		t.Errorf("Stats = %+v", st)
+	}
+	s.Close()
+
+	// Simulate a crash part way through a write.
+	segs, _ := filepath.Glob(filepath.Join(dir, "*.seg"))
+	f, _ := os.OpenFile(segs[len(segs)-1], os.O_WRONLY|os.O_APPEND, 0)
+	f.WriteString(`1234abcd [{"dimensionId":`)
+	f.Close()
+
+	fake.err = nil
+	s, err = OpenMetricSpool(dir, fake, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	resp, err := s.Flush(ctx)
+	if err != nil || resp.Processed != 4 {
+		t.Fatalf("Flush = %+v, %v", resp, err)
+	}
+	if got := sentIDs(fake); len(got) != 4 || got[0] != "a" || got[3] != "d" || len(fake.sent[0]) != 2 {
+		t.Errorf("sent %v in %d batches", got, len(fake.sent))
+	}
+	if st := s.Stats(); st.Segments != 1 || st.PendingBytes != 0 {
+		t.Errorf("Stats after flush = %+v", st)
+	}
+
+	// Nothing is sent again after reopening.
+	s.Write(spoolMetric("e"))
+	s.Close()
+	s, _ = OpenMetricSpool(dir, fake, opts)
+	fake.sent = nil
+	s.Flush(ctx)
+	if got := sentIDs(fake); len(got) != 1 || got[0] != "e" {
+		t.Errorf("sent %v after reopen", got)
+	}
+}
+
+func TestMetricSpoolDrop(t *testing.T) {
+	rec, _ := encodeSpoolRecord([]Metric{spoolMetric("a")})
+	size := int64(len(rec))
+	ctx := context.Background()
+
+	fake := &fakeMetrics{}
+	s, _ := OpenMetricSpool(t.TempDir(), fake, SpoolOptions{MaxBytes: 2 * size, SegmentBytes: size})
+	for _, id := range []string{"a", "b", "c"} {
+		if err := s.Write(spoolMetric(id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if st := s.Stats(); st.PendingBytes != 2*size || st.DroppedBytes != size {
+		t.Errorf("DropOldest Stats = %+v", st)
+	}
+
+	// A write larger than the spool is rejected without dropping the metrics already spooled.
+	if err := s.Write(spoolMetric("x"), spoolMetric("y"), spoolMetric("z")); !errors.Is(err, ErrSpoolFull) {
+		t.Errorf("oversized write err = %v", err)
+	}
+	s.Flush(ctx)
+	if got := sentIDs(fake); len(got) != 2 || got[0] != "b" || got[1] != "c" {
+		t.Errorf("DropOldest sent %v", got)
+	}
+	s.Close()
+
+	fake = &fakeMetrics{}
+	s, _ = OpenMetricSpool(t.TempDir(), fake, SpoolOptions{MaxBytes: 2 * size, Drop: DropNewest})
+	defer s.Close()
+	s.Write(spoolMetric("a"), spoolMetric("b"))
+	if err := s.Write(spoolMetric("c")); !errors.Is(err, ErrSpoolFull) {
+		t.Errorf("DropNewest err = %v", err)
+	}
+	s.Flush(ctx)
+	if got := sentIDs(fake); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("DropNewest sent %v", got)
+	}
+}
+
+func TestMetricSpoolInvalid(t *testing.T) {
+	s, _ := OpenMetricSpool(t.TempDir(), &fakeMetrics{err: ErrValidation}, SpoolOptions{})
+	defer s.Close()
+	var bve *BulkValidationError
+	if err := s.Write(spoolMetric("a"), Metric{}); !errors.As(err, &bve) || bve.Items[0].Index != 1 {
+		t.Errorf("Write err = %v", err)
+	}
+	if st := s.Stats(); st.PendingBytes != 0 {
+		t.Errorf("invalid write spooled %d bytes", st.PendingBytes)
+	}
+
+	// Metrics rejected by validation when sent, e.g. by a DimensionRegistry, are skipped rather than
+	// retried, and the rest of their batch is sent.
+	dry := NewDryRun(nil)
+	c, _ := New("key", WithTenantUUID("t"), WithDryRun(dry), WithDimensionRegistry(NewDimensionRegistry(false, "logins")))
+	s.MetricsAPI = c.MetricsService
+	typo := spoolMetric("b")
+	typo.DimensionID = String("logns")
+	s.Write(spoolMetric("a"), typo, spoolMetric("c"))
+	if _, err := s.Flush(context.Background()); !errors.Is(err, ErrValidation) {
+		t.Errorf("Flush err = %v", err)
+	}
+	if st := s.Stats(); st.PendingBytes != 0 || st.Skipped != 1 {
+		t.Errorf("Stats = %+v", st)
+	}
+	reqs := dry.Requests()
+	if len(reqs) != 1 || strings.Count(string(reqs[0].Body), "externalId") != 2 || strings.Contains(string(reqs[0].Body), "logns") {
+		t.Errorf("sent %v", reqs)
+	}
+}
+
+// rejectingMetrics fails batches containing a metric for the externalId bad with ErrBadRequest.
+type rejectingMetrics struct {
+	fakeMetrics
+	bad string
+}
+
+func (f *rejectingMetrics) BulkUpsert(ctx context.Context, metrics []Metric) (*UpsertMetricsResponse, error) {
+	for _, m := range metrics {
+		if m.GetExternalID() == f.bad {
+			return nil, ErrBadRequest
+		}
+	}
+	return f.fakeMetrics.BulkUpsert(ctx, metrics)
+}
+
+func TestMetricSpoolBadRequest(t *testing.T) {
+	// A batch planhat rejects is skipped rather than retried forever, and later batches are sent.
+	fake := &rejectingMetrics{bad: "b"}
+	s, _ := OpenMetricSpool(t.TempDir(), fake, SpoolOptions{BatchSize: 1})
+	defer s.Close()
+	s.Write(spoolMetric("a"), spoolMetric("b"), spoolMetric("c"))
+	if _, err := s.Flush(context.Background()); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("Flush err = %v", err)
+	}
+	if got := sentIDs(&fake.fakeMetrics); len(got) != 2 || got[0] != "a" || got[1] != "c" {
+		t.Errorf("sent %v", got)
+	}
+	if st := s.Stats(); st.PendingBytes != 0 || st.Skipped != 1 {
+		t.Errorf("Stats = %+v", st)
+	}
+	if _, err := s.Flush(context.Background()); err != nil {
+		t.Errorf("second Flush err = %v", err)
+	}
+}
+
+func TestMetricSpoolNormalisation(t *testing.T) {
+	c, _ := New("key", WithDimensionNormalisation(true))
+	fake := &fakeMetrics{}
+	s, _ := OpenMetricSpool(t.TempDir(), c.MetricsService, SpoolOptions{})
+	defer s.Close()
+	if err := s.Write(Metric{DimensionID: String("Active Users"), ExternalID: String("a"), Value: Float64(1)}); err != nil {
+		t.Errorf("Write: %v", err)
+	}
+	s.MetricsAPI = fake
+	s.Flush(context.Background())
+	if len(fake.sent) != 1 || fake.sent[0][0].GetDimensionID() != "activeusers" {
+		t.Errorf("sent %v", fake.sent)
+	}
+
+	// An aggregator in front of the spool normalises too.
+	agg := NewMetricAggregator(s, AggregateSum)
+	if err := agg.Add(Metric{DimensionID: String("Active Users"), ExternalID: String("a"), Value: Float64(1)}); err != nil {
+		t.Errorf("Add: %v", err)
+	}
+}
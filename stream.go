@@ -0,0 +1,44 @@
+package planhat
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// streamDecoder is implemented by values passed to makeRequest that decode the response body
+// themselves rather than having it decoded into them in one go.
+type streamDecoder interface {
+	decodeStream(r io.Reader) error
+}
+
+// arrayStream decodes a JSON array response one element at a time, passing each to fn, so that only
+// a single element is held in memory at once.
+type arrayStream[T any] struct {
+	fn func(*T) error
+}
+
+func (s arrayStream[T]) decodeStream(r io.Reader) error {
+	dec := json.NewDecoder(r)
+	tok, err := dec.Token()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '[' {
+		return fmt.Errorf("planhat: expected JSON array, got %v", tok)
+	}
+	for dec.More() {
+		item := new(T)
+		if err := dec.Decode(item); err != nil {
+			return err
+		}
+		if err := s.fn(item); err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token()
+	return err
+}
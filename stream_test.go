@@ -0,0 +1,87 @@
+package planhat
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLeanListEach(t *testing.T) {
+	const n = 10000
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/leancompanies" || r.URL.Query().Get("status") != "customer" {
+			t.Errorf("unexpected request %v", r.URL)
+		}
+		bw := bufio.NewWriter(w)
+		bw.WriteString("[")
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				bw.WriteString(",")
+			}
+			fmt.Fprintf(bw, `{"_id":"%d","name":"Company %d","externalId":"ext-%d"}`, i, i, i)
+		}
+		bw.WriteString("]")
+		bw.Flush()
+	}))
+	defer ts.Close()
+
+	c, _ := New("key", WithBaseURL(ts.URL))
+	ctx := context.Background()
+	count := 0
+	err := c.CompanyService.LeanListEach(ctx, func(co *LeanCompany) error {
+		if co.ID != fmt.Sprint(count) || co.ExternalID != fmt.Sprintf("ext-%d", count) {
+			t.Fatalf("company %d = %+v", count, co)
+		}
+		count++
+		return nil
+	}, &LeanCompanyListOptions{Status: String("customer")})
+	if err != nil || count != n {
+		t.Fatalf("LeanListEach = %v after %d companies", err, count)
+	}
+
+	errStop := errors.New("stop")
+	count = 0
+	err = c.CompanyService.LeanListEach(ctx, func(co *LeanCompany) error {
+		count++
+		if count == 3 {
+			return errStop
+		}
+		return nil
+	}, &LeanCompanyListOptions{Status: String("customer")})
+	if err != errStop || count != 3 {
+		t.Errorf("LeanListEach = %v after %d companies, want stop after 3", err, count)
+	}
+}
+
+func TestListEach(t *testing.T) {
+	tests := []struct {
+		body    string
+		want    int
+		wantErr bool
+	}{
+		{body: `[{"_id":"1"},{"_id":"2"}]`, want: 2},
+		{body: `[]`, want: 0},
+		{body: ``, want: 0},
+		{body: `{"_id":"1"}`, wantErr: true},
+		{body: `[{"_id":"1"},{"_id":`, want: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, tt.body)
+		}))
+		c, _ := New("key", WithBaseURL(ts.URL))
+		got := 0
+		err := c.AssetService.ListEach(context.Background(), func(a *Asset) error {
+			got++
+			return nil
+		})
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ListEach(%q) = %v, %d assets; want error %v, %d assets", tt.body, err, got, tt.wantErr, tt.want)
+		}
+		ts.Close()
+	}
+}
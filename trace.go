@@ -0,0 +1,51 @@
+package planhat
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+type operationKey struct{}
+
+type clientTraceKey struct{}
+
+// withOperation returns a context recording the name of the service method making a request, e.g.
+// "CompanyService.BulkUpsert".  If an operation is already recorded, such as when one service method
+// calls another, the outer operation is kept.
+func withOperation(ctx context.Context, op string) context.Context {
+	if OperationFromContext(ctx) != "" {
+		return ctx
+	}
+	return context.WithValue(ctx, operationKey{}, op)
+}
+
+// OperationFromContext returns the name of the service method that made a request, such as
+// "CompanyService.BulkUpsert", or an empty string if unknown.  Middleware can use it with the
+// request context to name spans or metrics.
+func OperationFromContext(ctx context.Context) string {
+	op, _ := ctx.Value(operationKey{}).(string)
+	return op
+}
+
+// ClientTrace is a set of hooks called during an API call, similar to httptrace.ClientTrace.  Any
+// hook may be nil.  Middleware can install a ClientTrace to observe events that happen further
+// down the chain, such as rate limiting and retries.
+type ClientTrace struct {
+	// RateLimitWait is called after the client has waited on the rate limiter before each attempt.
+	RateLimitWait func(attempt int, d time.Duration)
+
+	// Retry is called before the client waits to retry a failed attempt.  Either res or err is set.
+	Retry func(attempt int, wait time.Duration, res *http.Response, err error)
+}
+
+// WithClientTrace returns a context based on ctx that calls the hooks in trace during an API call.
+func WithClientTrace(ctx context.Context, trace *ClientTrace) context.Context {
+	return context.WithValue(ctx, clientTraceKey{}, trace)
+}
+
+// ContextClientTrace returns the ClientTrace associated with ctx, or nil.
+func ContextClientTrace(ctx context.Context) *ClientTrace {
+	trace, _ := ctx.Value(clientTraceKey{}).(*ClientTrace)
+	return trace
+}
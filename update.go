@@ -0,0 +1,195 @@
+package planhat
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// CompanyUpdate is a partial update of a company, sending only the fields that have been set or
+// cleared.  Unlike Update, which omits empty fields, it can clear a value by sending an explicit
+// null:
+//
+//	u := planhat.NewCompanyUpdate().SetPhase("Onboarding").ClearCustomerTo().SetCustom("Tier", "Gold")
+//	company, err := ph.CompanyService.UpdateFields(ctx, planhat.ByExternalID("acme"), u)
+//
+// Fields are named as in the planhat API, e.g. "customerTo", or set using the generated typed
+// methods such as SetPhase and ClearCustomerTo.
+type CompanyUpdate struct {
+	fieldUpdate
+}
+
+// NewCompanyUpdate returns an empty CompanyUpdate.
+func NewCompanyUpdate() *CompanyUpdate {
+	return &CompanyUpdate{newFieldUpdate(reflect.TypeOf(Company{}))}
+}
+
+// Set sets the named field to value.  A nil value clears the field.
+func (u *CompanyUpdate) Set(field string, value interface{}) *CompanyUpdate {
+	u.set(field, value)
+	return u
+}
+
+// Clear sets the named field to null.
+func (u *CompanyUpdate) Clear(field string) *CompanyUpdate {
+	u.set(field, nil)
+	return u
+}
+
+// SetCustom sets the named custom field to value.  A nil value clears the field.
+func (u *CompanyUpdate) SetCustom(name string, value interface{}) *CompanyUpdate {
+	u.setCustom(name, value)
+	return u
+}
+
+// ClearCustom sets the named custom field to null.
+func (u *CompanyUpdate) ClearCustom(name string) *CompanyUpdate {
+	u.setCustom(name, nil)
+	return u
+}
+
+// AssetUpdate is a partial update of an asset.  See CompanyUpdate for details.
+type AssetUpdate struct {
+	fieldUpdate
+}
+
+// NewAssetUpdate returns an empty AssetUpdate.
+func NewAssetUpdate() *AssetUpdate {
+	return &AssetUpdate{newFieldUpdate(reflect.TypeOf(Asset{}))}
+}
+
+// Set sets the named field to value.  A nil value clears the field.
+func (u *AssetUpdate) Set(field string, value interface{}) *AssetUpdate {
+	u.set(field, value)
+	return u
+}
+
+// Clear sets the named field to null.
+func (u *AssetUpdate) Clear(field string) *AssetUpdate {
+	u.set(field, nil)
+	return u
+}
+
+// SetCustom sets the named custom field to value.  A nil value clears the field.
+func (u *AssetUpdate) SetCustom(name string, value interface{}) *AssetUpdate {
+	u.setCustom(name, value)
+	return u
+}
+
+// ClearCustom sets the named custom field to null.
+func (u *AssetUpdate) ClearCustom(name string) *AssetUpdate {
+	u.setCustom(name, nil)
+	return u
+}
+
+// PartialUpdate is implemented by updates that send only some fields of a record, such as
+// CompanyUpdate and AssetUpdate.
+type PartialUpdate interface {
+	json.Marshaler
+	// Err returns any error recorded while building the update.
+	Err() error
+	// Fields returns the names of the fields in the update.
+	Fields() []string
+}
+
+// fieldUpdate holds the fields of a partial update.  A nil value is sent as null.
+type fieldUpdate struct {
+	model  reflect.Type
+	fields map[string]interface{}
+	custom map[string]interface{}
+	err    error
+}
+
+func newFieldUpdate(model reflect.Type) fieldUpdate {
+	return fieldUpdate{model: model, fields: map[string]interface{}{}, custom: map[string]interface{}{}}
+}
+
+func (p *fieldUpdate) set(field string, value interface{}) {
+	switch _, ok := jsonFields(p.model)[field]; {
+	case field == "custom":
+		p.fail(errors.New("planhat: use SetCustom or ClearCustom to update custom fields"))
+	case !ok:
+		p.fail(fmt.Errorf("planhat: unknown %s field %q", p.model.Name(), field))
+	default:
+		p.fields[field] = value
+	}
+}
+
+func (p *fieldUpdate) setCustom(name string, value interface{}) {
+	if name == "" {
+		p.fail(errors.New("planhat: empty custom field name"))
+		return
+	}
+	p.custom[name] = value
+}
+
+// fail records the first error, which is returned by Err and when the update is sent.
+func (p *fieldUpdate) fail(err error) {
+	if p.err == nil {
+		p.err = err
+	}
+}
+
+// Err returns the first error recorded while building the update, such as an unknown field name.
+func (p *fieldUpdate) Err() error {
+	return p.err
+}
+
+// Fields returns the names of the fields set or cleared, sorted, with custom fields prefixed by
+// "custom.".
+func (p *fieldUpdate) Fields() []string {
+	var names []string
+	for name := range p.fields {
+		names = append(names, name)
+	}
+	for name := range p.custom {
+		names = append(names, "custom."+name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MarshalJSON returns the update as the body of a PUT request.
+func (p *fieldUpdate) MarshalJSON() ([]byte, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	body := make(map[string]interface{}, len(p.fields)+1)
+	for name, value := range p.fields {
+		body[name] = value
+	}
+	if len(p.custom) > 0 {
+		body["custom"] = p.custom
+	}
+	return json.Marshal(body)
+}
+
+var jsonFieldCache sync.Map // of reflect.Type -> map[string]reflect.StructField
+
+// jsonFields returns the struct fields of t, which must be a struct type, keyed by their JSON names.
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	if f, ok := jsonFieldCache.Load(t); ok {
+		return f.(map[string]reflect.StructField)
+	}
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = f.Name
+		}
+		fields[name] = f
+	}
+	jsonFieldCache.Store(t, fields)
+	return fields
+}
@@ -0,0 +1,79 @@
+package planhat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCompanyUpdate(t *testing.T) {
+	from := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	u := NewCompanyUpdate().
+		SetPhase("Onboarding").
+		SetCustomerFrom(from).
+		ClearCustomerTo().
+		Set("mrr", 100).
+		SetCustom("Tier", "Gold").
+		ClearCustom("Legacy ID")
+	if err := u.Err(); err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"custom":{"Legacy ID":null,"Tier":"Gold"},"customerFrom":"2021-03-01T00:00:00Z","customerTo":null,"mrr":100,"phase":"Onboarding"}`
+	if string(b) != want {
+		t.Errorf("body = %s, want %s", b, want)
+	}
+	wantFields := []string{"custom.Legacy ID", "custom.Tier", "customerFrom", "customerTo", "mrr", "phase"}
+	if fmt.Sprint(u.Fields()) != fmt.Sprint(wantFields) {
+		t.Errorf("Fields = %q, want %q", u.Fields(), wantFields)
+	}
+
+	tests := []struct {
+		name string
+		u    PartialUpdate
+	}{
+		{"unknown field", NewCompanyUpdate().Set("phse", "x")},
+		{"custom via Set", NewAssetUpdate().Clear("custom")},
+		{"empty custom name", NewAssetUpdate().SetCustom("", 1)},
+	}
+	for _, tt := range tests {
+		if tt.u.Err() == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+		if _, err := json.Marshal(tt.u); err == nil {
+			t.Errorf("%s: expected marshal error", tt.name)
+		}
+	}
+}
+
+func TestUpdateFields(t *testing.T) {
+	var got []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = append(got, fmt.Sprintf("%s %s %s", r.Method, r.URL.EscapedPath(), body))
+		fmt.Fprint(w, `{"_id":"1"}`)
+	}))
+	defer ts.Close()
+
+	c, _ := New("key", WithBaseURL(ts.URL))
+	ctx := context.Background()
+	a, err := c.AssetService.UpdateFields(ctx, ByExternalID("a/1"), NewAssetUpdate().ClearCompanyID().SetName("Widget"))
+	if err != nil || a.GetID() != "1" {
+		t.Fatalf("UpdateFields = %+v, %v", a, err)
+	}
+	if _, err := c.CompanyService.UpdateFields(ctx, ByID("1"), NewCompanyUpdate().Clear("nope")); err == nil {
+		t.Error("expected error for unknown field")
+	}
+	want := []string{`PUT /assets/extid-a%2F1 {"companyId":null,"name":"Widget"}`}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got requests %q, want %q", got, want)
+	}
+}
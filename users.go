@@ -100,6 +100,7 @@ type User struct {
 
 // List returns a list of planhat users
 func (s *UserService) List(ctx context.Context) ([]*User, error) {
+	ctx = withOperation(ctx, "UserService.List")
 	ur := []*User{}
 	url := fmt.Sprintf("%s/users", s.client.BaseURL)
 	req, err := http.NewRequest("GET", url, nil)
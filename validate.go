@@ -0,0 +1,286 @@
+package planhat
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// FieldError describes a single invalid field.
+type FieldError struct {
+	// Field is the JSON name of the field, e.g. "name" or "custom.Tier".
+	Field string
+	// Message describes the problem.
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationError is returned when a record fails validation.  It lists every invalid field, and
+// wraps ErrValidation.
+type ValidationError struct {
+	// Model is the type of record, e.g. "Company".
+	Model string
+	// Fields are the problems found.
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Error()
+	}
+	return fmt.Sprintf("planhat: invalid %s: %s", e.Model, strings.Join(msgs, "; "))
+}
+
+func (e *ValidationError) Unwrap() error { return ErrValidation }
+
+// ItemError is the validation error of a single record in a bulk request.
+type ItemError struct {
+	// Index is the position of the record in the request.
+	Index int
+	// Err describes the problems with the record.  It is a *ValidationError.
+	Err error
+}
+
+// BulkValidationError is returned when records in a bulk request fail validation.  Nothing is sent.
+// It wraps ErrValidation.
+type BulkValidationError struct {
+	// Total is the number of records in the request.
+	Total int
+	// Items are the invalid records, in order.
+	Items []ItemError
+}
+
+func (e *BulkValidationError) Error() string {
+	const shown = 3
+	msgs := []string{}
+	for i, item := range e.Items {
+		if i == shown {
+			msgs = append(msgs, fmt.Sprintf("and %d more", len(e.Items)-shown))
+			break
+		}
+		msgs = append(msgs, fmt.Sprintf("[%d] %s", item.Index, strings.TrimPrefix(item.Err.Error(), "planhat: ")))
+	}
+	return fmt.Sprintf("planhat: %d of %d records invalid: %s", len(e.Items), e.Total, strings.Join(msgs, "; "))
+}
+
+func (e *BulkValidationError) Unwrap() error { return ErrValidation }
+
+// WithValidation enables or disables validation of records before they are sent by Create and
+// BulkUpsert.  It is enabled by default.  Invalid records are reported as a *ValidationError, or a
+// *BulkValidationError for bulk requests, rather than being rejected by planhat with ErrBadRequest.
+func WithValidation(enabled bool) Option {
+	return func(c *Client) error {
+		c.skipValidation = !enabled
+		return nil
+	}
+}
+
+// Validate checks that the company can be created: it must have a name, and any fields set must be
+// valid.
+func (c *Company) Validate() error {
+	v := c.validate()
+	v.require(strings.TrimSpace(c.GetName()) != "", "name")
+	return v.err()
+}
+
+// validateUpsert checks that the company can be bulk upserted: it must have a name, to be created, or
+// an _id or externalId, to be matched.
+func (c *Company) validateUpsert() error {
+	v := c.validate()
+	if strings.TrimSpace(c.GetName()) == "" && c.GetID() == "" && c.GetExternalID() == "" {
+		v.add("name", "required unless _id or externalId is set")
+	}
+	return v.err()
+}
+
+func (c *Company) validate() *validator {
+	v := &validator{model: "Company"}
+	v.notEmpty(c.ExternalID, "externalId")
+	v.custom(c.Custom)
+	return v
+}
+
+// Validate checks that the asset can be created: it must have a name and a companyId, and any fields
+// set must be valid.
+func (a *Asset) Validate() error {
+	v := a.validate()
+	v.require(strings.TrimSpace(a.GetName()) != "", "name")
+	v.require(a.GetCompanyID() != "", "companyId")
+	return v.err()
+}
+
+// validateUpsert checks that the asset can be bulk upserted: it must have a name and companyId, to be
+// created, or an _id, externalId or sourceId, to be matched.
+func (a *Asset) validateUpsert() error {
+	v := a.validate()
+	if a.GetID() == "" && a.GetExternalID() == "" && a.GetSourceID() == "" {
+		v.require(strings.TrimSpace(a.GetName()) != "", "name")
+		v.require(a.GetCompanyID() != "", "companyId")
+	}
+	return v.err()
+}
+
+func (a *Asset) validate() *validator {
+	v := &validator{model: "Asset"}
+	v.notEmpty(a.ExternalID, "externalId")
+	v.notEmpty(a.SourceID, "sourceId")
+	v.custom(a.Custom)
+	return v
+}
+
+// Validate checks that the license can be created: it must have a companyId and a product.
+func (l *License) Validate() error {
+	v := &validator{model: "License"}
+	v.require(l.CompanyID != "", "companyId")
+	v.require(strings.TrimSpace(l.Product) != "", "product")
+	v.custom(l.Custom)
+	return v.err()
+}
+
+// Validate checks that the user can be created: it must have a firstName, lastName and email.
+func (u *User) Validate() error {
+	v := &validator{model: "User"}
+	v.require(strings.TrimSpace(u.GetFirstName()) != "", "firstName")
+	v.require(strings.TrimSpace(u.GetLastName()) != "", "lastName")
+	v.require(strings.TrimSpace(u.GetEmail()) != "", "email")
+	return v.err()
+}
+
+// metricModels are the models metrics can be sent for.
+var metricModels = map[string]bool{"Company": true, "EndUser": true, "Asset": true, "Project": true}
+
+// Validate checks that the metric has a dimensionId, value and externalId, and that its dimensionId,
+// model and date are valid.
+func (m *Metric) Validate() error {
+	return m.validate().err()
+}
+
+func (m *Metric) validate() *validator {
+	v := &validator{model: "Metric"}
+	switch id := m.GetDimensionID(); {
+	case id == "":
+		v.add("dimensionId", "required")
+	case !ValidDimensionID(id):
+		v.add("dimensionId", "must only contain letters, digits and underscores")
+	}
+	switch {
+	case m.Value == nil:
+		v.add("value", "required")
+	case math.IsNaN(*m.Value) || math.IsInf(*m.Value, 0):
+		v.add("value", "must be a finite number")
+	}
+	v.require(m.GetExternalID() != "", "externalId")
+	if m.Model != nil && !metricModels[*m.Model] {
+		v.add("model", "must be one of Company, EndUser, Asset or Project")
+	}
+	if _, ok := m.Time(); m.Date != nil && !ok {
+		v.add("date", "must be an ISO 8601 date")
+	}
+	return v
+}
+
+// validator collects the problems found with a record.
+type validator struct {
+	model  string
+	fields []FieldError
+}
+
+func (v *validator) add(field, msg string) {
+	v.fields = append(v.fields, FieldError{Field: field, Message: msg})
+}
+
+func (v *validator) require(ok bool, field string) {
+	if !ok {
+		v.add(field, "required")
+	}
+}
+
+func (v *validator) notEmpty(s *string, field string) {
+	if s != nil && strings.TrimSpace(*s) == "" {
+		v.add(field, "must not be empty if set")
+	}
+}
+
+func (v *validator) custom(custom map[string]interface{}) {
+	for name := range custom {
+		if strings.TrimSpace(name) == "" {
+			v.add("custom", "field names must not be empty")
+			return
+		}
+	}
+}
+
+func (v *validator) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Model: v.model, Fields: v.fields}
+}
+
+// validateOne validates item before it is created, if validation is enabled and it has a Validate
+// method.
+func (c *Client) validateOne(item interface{}) error {
+	if c.skipValidation {
+		return nil
+	}
+	if v, ok := item.(interface{ Validate() error }); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+// validateBulk validates items before they are bulk upserted, if validation is enabled, using their
+// validateUpsert method if they have one or Validate if not.
+func validateBulk[T any](c *Client, items []T) error {
+	if c.skipValidation {
+		return nil
+	}
+	var errs []ItemError
+	for i := range items {
+		var err error
+		switch v := any(&items[i]).(type) {
+		case interface{ validateUpsert() error }:
+			err = v.validateUpsert()
+		case interface{ Validate() error }:
+			err = v.Validate()
+		}
+		if ve, ok := err.(*ValidationError); ok {
+			errs = append(errs, ItemError{Index: i, Err: ve})
+		} else if err != nil {
+			return err
+		}
+	}
+	return bulkError(len(items), errs)
+}
+
+// validateMetrics validates metrics before they are bulk upserted, if validation is enabled, checking
+// their dimension IDs against the client's DimensionRegistry if it has one.
+func (c *Client) validateMetrics(metrics []Metric) error {
+	if c.skipValidation {
+		return nil
+	}
+	var errs []ItemError
+	for i := range metrics {
+		v := metrics[i].validate()
+		if id := metrics[i].GetDimensionID(); c.dimensions != nil && ValidDimensionID(id) {
+			if err := c.dimensions.Check(id); err != nil {
+				v.add("dimensionId", err.Error())
+			}
+		}
+		if err := v.err(); err != nil {
+			errs = append(errs, ItemError{Index: i, Err: err})
+		}
+	}
+	return bulkError(len(metrics), errs)
+}
+
+func bulkError(total int, errs []ItemError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &BulkValidationError{Total: total, Items: errs}
+}
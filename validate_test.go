@@ -0,0 +1,98 @@
+package planhat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	from := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(-1, 0, 0)
+	tests := []struct {
+		name string
+		v    interface{ Validate() error }
+		want string
+	}{
+		{"valid company", &Company{Name: String("Acme")}, ""},
+		{"company", &Company{ExternalID: String(" "), CSMScore: Int(7), CustomerFrom: &from, CustomerTo: &to},
+			"planhat: invalid Company: externalId: must not be empty if set; name: required"},
+		{"valid asset", &Asset{Name: String("Widget"), CompanyID: String("1")}, ""},
+		{"asset", &Asset{Custom: map[string]interface{}{"": 1}},
+			"planhat: invalid Asset: custom: field names must not be empty; name: required; companyId: required"},
+		{"valid metric", &Metric{DimensionID: String("logins"), Value: Float64(0), ExternalID: String("a"), Date: String("2021-03-01")}, ""},
+		{"metric", &Metric{DimensionID: String("active users"), Value: Float64(math.NaN()), Model: String("Deal"), Date: String("yesterday")},
+			"planhat: invalid Metric: dimensionId: must only contain letters, digits and underscores; value: must be a finite number; externalId: required; model: must be one of Company, EndUser, Asset or Project; date: must be an ISO 8601 date"},
+		{"empty metric", &Metric{}, "planhat: invalid Metric: dimensionId: required; value: required; externalId: required"},
+		{"valid license", &License{CompanyID: "1", Product: "Platform"}, ""},
+		{"license", &License{Product: " "}, "planhat: invalid License: companyId: required; product: required"},
+		{"valid user", &User{FirstName: String("Ada"), LastName: String("Lovelace"), Email: String("ada@example.com")}, ""},
+		{"user", &User{FirstName: String("Ada")}, "planhat: invalid User: lastName: required; email: required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.v.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("err = %v\nwant %s", err, tt.want)
+			}
+			if !errors.Is(err, ErrValidation) {
+				t.Errorf("err does not wrap ErrValidation")
+			}
+		})
+	}
+}
+
+func TestValidationBeforeWrite(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `{}`)
+	}))
+	defer ts.Close()
+	ctx := context.Background()
+
+	c, _ := New("key", WithBaseURL(ts.URL), WithMetricsURL(ts.URL), WithTenantUUID("t"))
+	if _, err := c.AssetService.Create(ctx, Asset{Name: String("Widget")}); !errors.Is(err, ErrValidation) {
+		t.Errorf("Create err = %v, want ErrValidation", err)
+	}
+
+	// In bulk, records with a keyable don't need the fields required to create them.
+	_, err := c.CompanyService.BulkUpsert(ctx, []Company{{Name: String("a")}, {}, {ExternalID: String("c")}, {ExternalID: String(" "), ID: String("d")}})
+	var bve *BulkValidationError
+	if !errors.As(err, &bve) {
+		t.Fatalf("BulkUpsert err = %v, want *BulkValidationError", err)
+	}
+	if bve.Total != 4 || len(bve.Items) != 2 || bve.Items[0].Index != 1 || bve.Items[1].Index != 3 {
+		t.Errorf("BulkValidationError = %+v", bve)
+	}
+	want := "planhat: 2 of 4 records invalid: [1] invalid Company: name: required unless _id or externalId is set; [3] invalid Company: externalId: must not be empty if set"
+	if err.Error() != want {
+		t.Errorf("err = %v\nwant %s", err, want)
+	}
+
+	if _, err := c.MetricsService.BulkUpsert(ctx, []Metric{{DimensionID: String("x")}}); !errors.Is(err, ErrValidation) {
+		t.Errorf("metrics BulkUpsert err = %v, want ErrValidation", err)
+	}
+	if requests != 0 {
+		t.Errorf("%d requests sent for invalid records", requests)
+	}
+
+	c, _ = New("key", WithBaseURL(ts.URL), WithValidation(false))
+	if _, err := c.AssetService.Create(ctx, Asset{}); err != nil {
+		t.Errorf("Create without validation err = %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("requests = %d, want 1", requests)
+	}
+}